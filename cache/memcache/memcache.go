@@ -0,0 +1,179 @@
+// Package memcache provides a single, process-wide, memory-bounded LRU used
+// to cache the expensive parts of a build - rendered markdown, generated
+// tables of contents, and compiled ace templates - so that repeated `serve`
+// rebuilds and the concurrent pool.Task workers within a single build don't
+// redo the same work over and over.
+package memcache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Kind categorizes what sort of thing a Key's entry holds, purely for
+// debugging/stats purposes; it's part of the key so that, say, a markdown
+// render and a TOC render of the same source never collide.
+type Kind string
+
+const (
+	// KindMarkdown is a source file's rendered HTML.
+	KindMarkdown Kind = "markdown"
+
+	// KindTOC is a rendered table of contents.
+	KindTOC Kind = "toc"
+
+	// KindTemplate is a compiled ace template.
+	KindTemplate Kind = "template"
+)
+
+// Key identifies a single cache entry: what kind of thing it is, the path
+// it was produced from, and a hash of the content that produced it (so that
+// editing the file invalidates the entry without anyone having to remember
+// to evict it explicitly).
+type Key struct {
+	Kind        Kind
+	Path        string
+	ContentHash string
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s:%s:%s", k.Kind, k.Path, k.ContentHash)
+}
+
+type entry struct {
+	key   Key
+	value any
+	size  int64
+}
+
+// Cache is a memory-bounded LRU. The zero value is not usable; construct one
+// with New.
+type Cache struct {
+	mu      sync.Mutex
+	budget  int64
+	used    int64
+	entries map[Key]*list.Element
+	order   *list.List // front = most recently used
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// New returns an empty Cache that will evict its least-recently-used entries
+// once the total size of its entries (as reported by each entry's size
+// function; see GetOrCompute) exceeds budget bytes.
+func New(budget int64) *Cache {
+	return &Cache{
+		budget:  budget,
+		entries: make(map[Key]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Default is the process-wide cache that markdown/TOC/template rendering is
+// wrapped with. Its budget defaults to a quarter of the process's reported
+// system memory, or MEMORY_LIMIT GiB if that environment variable is set.
+var Default = New(DefaultBudget())
+
+// DefaultBudget computes the default byte budget for Default: MEMORY_LIMIT
+// (expressed in GiB) if set, otherwise a quarter of
+// runtime.MemStats.Sys-derived total.
+func DefaultBudget() int64 {
+	if raw := os.Getenv("MEMORY_LIMIT"); raw != "" {
+		if gib, err := strconv.ParseFloat(raw, 64); err == nil && gib > 0 {
+			return int64(gib * (1 << 30))
+		}
+	}
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return int64(stats.Sys) / 4
+}
+
+// GetOrCompute returns the cached value for key if present, or else calls
+// compute, stores its result (sized via size), and returns it. compute's
+// error, if any, is returned without being cached.
+//
+// GetOrCompute is a free function rather than a method because Go doesn't
+// allow generic methods on non-generic types.
+func GetOrCompute[T any](c *Cache, key Key, size func(T) int64, compute func() (T, error)) (T, error) {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		value := el.Value.(*entry).value.(T)
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return value, nil
+	}
+	c.mu.Unlock()
+
+	c.misses.Add(1)
+
+	value, err := compute()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	c.put(key, value, size(value))
+	return value, nil
+}
+
+func (c *Cache) put(key Key, value any, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.used -= el.Value.(*entry).size
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+
+	el := c.order.PushFront(&entry{key: key, value: value, size: size})
+	c.entries[key] = el
+	c.used += size
+
+	c.evictLocked()
+}
+
+// evictLocked evicts least-recently-used entries until the cache is back
+// under budget. c.mu must be held.
+func (c *Cache) evictLocked() {
+	for c.used > c.budget {
+		back := c.order.Back()
+		if back == nil {
+			return
+		}
+
+		e := back.Value.(*entry)
+		c.order.Remove(back)
+		delete(c.entries, e.key)
+		c.used -= e.size
+	}
+}
+
+// ByteSize is a size function (see GetOrCompute) for cached values that are
+// raw byte slices.
+func ByteSize(b []byte) int64 {
+	return int64(len(b))
+}
+
+// StringSize is a size function (see GetOrCompute) for cached values that
+// are strings.
+func StringSize(s string) int64 {
+	return int64(len(s))
+}
+
+// Hash returns a content hash of data suitable for use as a Key's
+// ContentHash.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
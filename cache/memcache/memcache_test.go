@@ -0,0 +1,90 @@
+package memcache
+
+import (
+	"fmt"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestGetOrComputeHitsAndMisses(t *testing.T) {
+	c := New(1 << 20)
+	key := Key{Kind: KindMarkdown, Path: "articles/hello.md", ContentHash: "abc"}
+
+	var calls int
+	compute := func() (string, error) {
+		calls++
+		return "<p>hello</p>", nil
+	}
+
+	value, err := GetOrCompute(c, key, StringSize, compute)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>hello</p>", value)
+	assert.Equal(t, 1, calls)
+
+	// Second lookup with the same key is a hit: compute isn't called again.
+	value, err = GetOrCompute(c, key, StringSize, compute)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>hello</p>", value)
+	assert.Equal(t, 1, calls)
+
+	stats := c.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+
+	// A different content hash (the source file changed) misses.
+	key.ContentHash = "def"
+	_, err = GetOrCompute(c, key, StringSize, compute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestGetOrComputePropagatesError(t *testing.T) {
+	c := New(1 << 20)
+	key := Key{Kind: KindTOC, Path: "articles/hello.md", ContentHash: "abc"}
+
+	_, err := GetOrCompute(c, key, StringSize, func() (string, error) {
+		return "", fmt.Errorf("boom")
+	})
+	assert.Error(t, err)
+
+	// A failed compute isn't cached, so a retry calls compute again.
+	var calls int
+	_, err = GetOrCompute(c, key, StringSize, func() (string, error) {
+		calls++
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestEviction(t *testing.T) {
+	c := New(10)
+
+	for i := 0; i < 5; i++ {
+		key := Key{Kind: KindMarkdown, Path: fmt.Sprintf("article-%d", i), ContentHash: "x"}
+		_, err := GetOrCompute(c, key, StringSize, func() (string, error) {
+			return "12345", nil // 5 bytes each; budget is 10, so at most 2 fit
+		})
+		assert.NoError(t, err)
+	}
+
+	stats := c.Stats()
+	assert.LessOrEqual(t, stats.Used, int64(10))
+
+	// The most recently added entry should still be a hit.
+	lastKey := Key{Kind: KindMarkdown, Path: "article-4", ContentHash: "x"}
+	var calls int
+	_, err := GetOrCompute(c, lastKey, StringSize, func() (string, error) {
+		calls++
+		return "12345", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, calls)
+}
+
+func TestHumanBytes(t *testing.T) {
+	assert.Equal(t, "512B", humanBytes(512))
+	assert.Equal(t, "1.0KiB", humanBytes(1024))
+	assert.Equal(t, "1.0MiB", humanBytes(1024*1024))
+}
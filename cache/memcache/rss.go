@@ -0,0 +1,80 @@
+package memcache
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WatchRSS periodically checks the process's actual resident set size and,
+// if it's grown to more than double the cache's budget, shrinks the cache
+// to half its budget to relieve the pressure. It's meant to catch the case
+// where the budget was sized too optimistically (lots of non-cache
+// allocations happening elsewhere) so that a long-running `serve` session
+// doesn't OOM. It returns a function that stops the watch.
+func (c *Cache) WatchRSS(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if rss, err := readRSS(); err == nil && rss > c.budget*2 {
+					c.shrinkTo(c.budget / 2)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// shrinkTo temporarily lowers the cache's effective budget to evict down to
+// target bytes, then restores the configured budget so that normal
+// cache-filling afterward still honors it.
+func (c *Cache) shrinkTo(target int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	configured := c.budget
+	c.budget = target
+	c.evictLocked()
+	c.budget = configured
+}
+
+// readRSS reads the process's resident set size out of /proc/self/status.
+// It only works on Linux; callers should treat any error (including "not on
+// Linux") as "couldn't determine RSS, skip this check."
+func readRSS() (int64, error) {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "VmRSS:") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return 0, fmt.Errorf("memcache: malformed VmRSS line %q", line)
+		}
+
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		return kb * 1024, nil
+	}
+
+	return 0, fmt.Errorf("memcache: VmRSS not found in /proc/self/status")
+}
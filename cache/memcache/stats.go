@@ -0,0 +1,56 @@
+package memcache
+
+import "fmt"
+
+// Stats is a snapshot of a Cache's hit/miss counters and current usage, for
+// the `--stats` CLI flag.
+type Stats struct {
+	Hits   int64
+	Misses int64
+	Used   int64
+	Budget int64
+}
+
+// Stats returns a snapshot of c's current counters and usage.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Used:   c.used,
+		Budget: c.budget,
+	}
+}
+
+// HitRate returns the fraction (0-1) of lookups that were satisfied from
+// cache. It's 0 if there have been no lookups at all.
+func (s Stats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// String renders the stats in the one-line form the CLI prints.
+func (s Stats) String() string {
+	return fmt.Sprintf("cache: hits=%d misses=%d hit-rate=%.1f%% used=%s budget=%s",
+		s.Hits, s.Misses, s.HitRate()*100, humanBytes(s.Used), humanBytes(s.Budget))
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+
+	singularity "github.com/jmyers/brandur-singularity"
+	"github.com/jmyers/brandur-singularity/markdown"
+)
+
+// runGen dispatches the `gen` command's own subcommands.
+func runGen(args []string) error {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+		args = args[1:]
+	}
+
+	switch name {
+	case "chromastyles":
+		return runGenChromaStyles(args)
+	default:
+		return fmt.Errorf("unknown gen subcommand: %v", name)
+	}
+}
+
+// runGenChromaStyles writes the standalone stylesheet for the fenced code
+// block classes Chroma's HTML formatter emits (see markdown.GoldmarkRenderer)
+// into the versioned assets dir, so the stylesheet pipeline can @import it.
+func runGenChromaStyles(args []string) error {
+	fs := flag.NewFlagSet("gen chromastyles", flag.ExitOnError)
+	style := fs.String("style", markdown.DefaultChromaStyle(), "Chroma style to render")
+	inlineLineNumbers := fs.Bool("line-numbers-inline-style", false,
+		"Number lines with an inline <span> on each one")
+	tableLineNumbers := fs.Bool("line-numbers-table-style", false,
+		"Number lines in their own table column")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	versionedAssetsDir := path.Join(singularity.TargetDir, "assets", singularity.Release)
+	if err := os.MkdirAll(versionedAssetsDir, 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(path.Join(versionedAssetsDir, "chroma.css"))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return markdown.WriteChromaCSS(out, *style, markdown.ChromaCSSOptions{
+		LineNumbersInlineStyle: *inlineLineNumbers,
+		LineNumbersTableStyle:  *tableLineNumbers,
+	})
+}
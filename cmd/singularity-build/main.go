@@ -2,6 +2,9 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
@@ -11,7 +14,11 @@ import (
 	log "github.com/Sirupsen/logrus"
 	singularity "github.com/jmyers/brandur-singularity"
 	"github.com/jmyers/brandur-singularity/assets"
+	"github.com/jmyers/brandur-singularity/cache/memcache"
+	"github.com/jmyers/brandur-singularity/content"
+	"github.com/jmyers/brandur-singularity/deps"
 	"github.com/jmyers/brandur-singularity/markdown"
+	"github.com/jmyers/brandur-singularity/modules"
 	"github.com/jmyers/brandur-singularity/pool"
 	"github.com/jmyers/brandur-singularity/templatehelpers"
 	"github.com/jmyers/brandur-singularity/toc"
@@ -19,12 +26,29 @@ import (
 	"github.com/yosssi/ace"
 )
 
+// depsGraphPath is where the dependency graph built up during a build is
+// persisted between runs, so that a later invocation (e.g. `graph`) can load
+// it without needing to have been the process that built it. `serve` loads
+// it too, but its startup build rebuilds everything regardless.
+const depsGraphPath = singularity.TargetDir + "/.singularity-deps.gob"
+
 // Conf contains configuration information for the command.
 type Conf struct {
 	// Concurrency is how main background Goroutines will be used to build all
 	// site resources (e.g. articles, pages, etc.).
 	Concurrency int `env:"CONCURRENCY,default=10"`
 
+	// ArticleFeeds is a semicolon-delimited list of additional JSON feed
+	// locations (see content.JSONFeedSource) whose pages are unioned with
+	// the ones read from the articles directory, so that content can be
+	// pulled from an external system without the template layer needing to
+	// change.
+	ArticleFeeds []string `env:"ARTICLE_FEEDS"`
+
+	// DisableBrowserError turns off the in-browser error overlay in `serve`
+	// mode so that build errors are only printed to the console.
+	DisableBrowserError bool `env:"DISABLE_BROWSER_ERROR,default=false"`
+
 	// GoogleAnalyticsID is the account identifier for Google Analytics to use.
 	GoogleAnalyticsID string `env:"GOOGLE_ANALYTICS_ID"`
 
@@ -35,6 +59,9 @@ type Conf struct {
 	// where you otherwise wouldn't have the fonts.
 	LocalFonts bool `env:"LOCAL_FONTS,default=false"`
 
+	// ServeAddr is the address that `serve` binds its HTTP server to.
+	ServeAddr string `env:"SERVE_ADDR,default=:5001"`
+
 	// Verbose is whether the program will print debug output as it's running.
 	Verbose bool `env:"VERBOSE,default=false"`
 }
@@ -47,65 +74,150 @@ type Conf struct {
 // very many places and can probably be refactored as a local if desired.
 var conf Conf
 
+// moduleFS is the project's layered view over its own files and any theme
+// modules it imports, per its singularity.mod. It's nil for a project with
+// no manifest, in which case every lookup falls back to the project's own
+// directories exactly as it did before modules existed.
+var moduleFS *modules.FS
+
+// depsGraph records, for every output the build writes, the inputs that
+// were consumed to produce it. `serve` diffs changed files against it to
+// figure out the minimal set of outputs to rebuild; `build` re-records every
+// output it writes over the graph loaded at startup, so entries for outputs
+// it no longer writes are kept.
+var depsGraph *deps.Graph
+
 //
 // Main
 //
 
 func main() {
+	err := envdecode.Decode(&conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	singularity.InitLog(conf.Verbose)
+
+	moduleFS, err = modules.Load(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	depsGraph, err = deps.LoadGraph(depsGraphPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	command := "build"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	switch command {
+	case "build":
+		err = runBuild()
+	case "serve":
+		err = runServe()
+	case "mod":
+		err = runMod(argsAfterCommand())
+	case "graph":
+		err = depsGraph.PrintGraph(os.Stdout)
+	case "gen":
+		err = runGen(argsAfterCommand())
+	default:
+		log.Fatalf("Unknown command: %v", command)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runBuild builds the site once, end to end, and exits non-zero if any task
+// failed.
+func runBuild() error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	showStats := fs.Bool("stats", false, "Print render cache hit/miss statistics after building")
+	if err := fs.Parse(argsAfterCommand()); err != nil {
+		return err
+	}
+
 	start := time.Now()
 	defer func() {
 		log.Infof("Built site in %v.", time.Since(start))
+		if *showStats {
+			log.Info(memcache.Default.Stats())
+		}
 	}()
 
-	err := envdecode.Decode(&conf)
+	tasks, err := buildTasks()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	singularity.InitLog(conf.Verbose)
+	ok := runTasks(tasks)
 
-	// This is where we stored "versioned" assets like compiled JS and CSS.
-	// These assets have a release number that we can increment and by
-	// extension quickly invalidate.
-	versionedAssetsDir := path.Join(singularity.TargetDir, "assets",
-		singularity.Release)
+	if err := depsGraph.Save(depsGraphPath); err != nil {
+		log.Error(err)
+	}
 
-	err = singularity.CreateOutputDirs(singularity.TargetDir)
+	if !ok {
+		os.Exit(1)
+	}
+
+	return nil
+}
+
+// buildTasks assembles the full list of tasks needed to build the site from
+// scratch: linking static assets, compiling JS/CSS, and rendering every
+// article. It's shared between `build`, which runs it once, and `serve`,
+// which reruns it on every detected change.
+func buildTasks() ([]*pool.Task, error) {
+	err := singularity.CreateOutputDirs(singularity.TargetDir)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	var tasks []*pool.Task
 
-	tasks = append(tasks, pool.NewTask(func() error {
-		return linkFonts()
-	}))
+	fontTask, err := fontsTask()
+	if err != nil {
+		return nil, err
+	}
+	tasks = append(tasks, fontTask)
 
-	tasks = append(tasks, pool.NewTask(func() error {
-		return linkImages()
-	}))
+	imageTasks, err := imagesTasks()
+	if err != nil {
+		return nil, err
+	}
+	tasks = append(tasks, imageTasks...)
 
-	tasks = append(tasks, pool.NewTask(func() error {
-		return assets.CompileJavascripts(
-			path.Join(singularity.ContentDir, "javascripts"),
-			path.Join(versionedAssetsDir, "app.js"))
-	}))
+	jsTask, err := javascriptsTask()
+	if err != nil {
+		return nil, err
+	}
+	tasks = append(tasks, jsTask)
 
-	tasks = append(tasks, pool.NewTask(func() error {
-		return assets.CompileStylesheets(
-			path.Join(singularity.ContentDir, "stylesheets"),
-			path.Join(versionedAssetsDir, "app.css"))
-	}))
+	cssTask, err := stylesheetsTask()
+	if err != nil {
+		return nil, err
+	}
+	tasks = append(tasks, cssTask)
 
-	articleTasks, err := tasksForArticles()
+	pages, err := articlePages()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	tasks = append(tasks, articleTasks...)
+	tasks = append(tasks, tasksForArticles(pages)...)
 
-	if !runTasks(tasks) {
-		os.Exit(1)
+	aliasTasks, err := tasksForAliases(pages)
+	if err != nil {
+		return nil, err
 	}
+	tasks = append(tasks, aliasTasks...)
+
+	return tasks, nil
 }
 
 //
@@ -115,94 +227,324 @@ func main() {
 // They are normally run concurrently.
 //
 
-func linkFonts() error {
+// fontsTask returns the task that symlinks the whole fonts directory into
+// TargetDir/assets/fonts, recording the fonts directory's absolute path as
+// the output's only dependency. The watcher reports a change by the path of
+// the individual font file, which never equals that directory path, so a
+// font change isn't matched in the graph and `serve` falls back to a full
+// rebuild for it.
+func fontsTask() (*pool.Task, error) {
+	fontsDir, err := rootDir("content/fonts", path.Join(singularity.ContentDir, "fonts"))
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := filepath.Abs(fontsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return pool.NewTask(func() error {
+		return linkFonts(source)
+	}), nil
+}
+
+func linkFonts(source string) error {
 	start := time.Now()
 	defer func() {
 		log.Debugf("Linked font assets in %v.", time.Since(start))
 	}()
 
-	source, err := filepath.Abs(path.Join(singularity.ContentDir, "fonts"))
+	dest, err := filepath.Abs(path.Join(singularity.TargetDir, "assets", "fonts"))
 	if err != nil {
 		return err
 	}
 
-	dest, err := filepath.Abs(path.Join(singularity.TargetDir, "assets", "fonts"))
-	if err != nil {
+	if err := ensureSymlink(source, dest); err != nil {
 		return err
 	}
 
-	return ensureSymlink(source, dest)
+	set := deps.NewDepSet()
+	set.Add(deps.KindImage, source)
+	depsGraph.Record("assets/fonts", source, set)
+
+	return nil
 }
 
-func linkImages() error {
-	start := time.Now()
-	defer func() {
-		log.Debugf("Linked image assets in %v.", time.Since(start))
-	}()
+// imagesTasks returns one task per file in the images directory, each
+// relinking (and recording against depsGraph) just that one image - so that
+// editing a single image only has to relink that image, not the whole
+// directory.
+func imagesTasks() ([]*pool.Task, error) {
+	imagesDir, err := rootDir("content/images", singularity.ContentDir+"/images")
+	if err != nil {
+		return nil, err
+	}
 
-	assets, err := os.ReadDir(singularity.ContentDir + "/images")
+	entries, err := os.ReadDir(imagesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*pool.Task
+	for _, entry := range entries {
+		name := entry.Name()
+		tasks = append(tasks, imageTask(imagesDir, name))
+	}
+
+	return tasks, nil
+}
+
+// imageTask returns the task that relinks a single image named name out of
+// imagesDir.
+func imageTask(imagesDir, name string) *pool.Task {
+	return pool.NewTask(func() error {
+		return relinkImage(imagesDir, name)
+	})
+}
+
+func relinkImage(imagesDir, name string) error {
+	// we use absolute paths for source and destination because not doing
+	// so can result in some weird symbolic link inception
+	source, err := filepath.Abs(path.Join(imagesDir, name))
 	if err != nil {
 		return err
 	}
 
-	for _, asset := range assets {
-		// we use absolute paths for source and destination because not doing
-		// so can result in some weird symbolic link inception
-		source, err := filepath.Abs(singularity.ContentDir + "/images/" + asset.Name())
-		if err != nil {
+	dest, err := filepath.Abs(singularity.TargetDir + "/assets/" + name)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureSymlink(source, dest); err != nil {
+		return err
+	}
+
+	// Recorded under the relative path the watcher reports a change to this
+	// image by, not the absolute symlink source, so the two can match.
+	set := deps.NewDepSet()
+	set.Add(deps.KindImage, path.Join(imagesDir, name))
+	depsGraph.Record(path.Join("assets", name), source, set)
+
+	return nil
+}
+
+// javascriptsTask returns the task that compiles every file in the
+// javascripts directory into a single bundle, recording its output against
+// the javascripts directory's own path.
+func javascriptsTask() (*pool.Task, error) {
+	javascriptsDir, err := rootDir("content/javascripts", path.Join(singularity.ContentDir, "javascripts"))
+	if err != nil {
+		return nil, err
+	}
+
+	return pool.NewTask(func() error {
+		out := path.Join(versionedAssetsDir(), "app.js")
+		if err := assets.CompileJavascripts(javascriptsDir, out); err != nil {
 			return err
 		}
+		return recordAssetDeps(javascriptsDir, out)
+	}), nil
+}
 
-		dest, err := filepath.Abs(singularity.TargetDir + "/assets/" + asset.Name())
-		if err != nil {
+// stylesheetsTask returns the task that compiles every file in the
+// stylesheets directory into a single bundle, recording its output against
+// the stylesheets directory's own path.
+func stylesheetsTask() (*pool.Task, error) {
+	stylesheetsDir, err := rootDir("content/stylesheets", path.Join(singularity.ContentDir, "stylesheets"))
+	if err != nil {
+		return nil, err
+	}
+
+	return pool.NewTask(func() error {
+		out := path.Join(versionedAssetsDir(), "app.css")
+		if err := assets.CompileStylesheets(stylesheetsDir, out); err != nil {
 			return err
 		}
+		return recordAssetDeps(stylesheetsDir, out)
+	}), nil
+}
 
-		err = ensureSymlink(source, dest)
-		if err != nil {
-			return err
+// versionedAssetsDir is where "versioned" assets like compiled JS and CSS
+// are stored. These assets have a release number that we can increment and
+// by extension quickly invalidate.
+func versionedAssetsDir() string {
+	return path.Join(singularity.TargetDir, "assets", singularity.Release)
+}
+
+// recordAssetDeps records out's dependency set as every file directly under
+// dir, attributing it to dir itself so that an edit to any one JS/CSS
+// source file is recognized as affecting just this bundle's compile task
+// (see assetTaskForSource) rather than forcing a full rebuild -
+// assets.CompileJavascripts and assets.CompileStylesheets always recompile
+// their whole bundle, so there's no finer granularity to offer than that.
+func recordAssetDeps(dir, out string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	set := deps.NewDepSet()
+	for _, entry := range entries {
+		if entry.IsDir() || isHidden(entry.Name()) {
+			continue
 		}
+		set.Add(deps.KindImage, path.Join(dir, entry.Name()))
 	}
 
+	rel, err := filepath.Rel(singularity.TargetDir, out)
+	if err != nil {
+		return err
+	}
+	depsGraph.Record(rel, dir, set)
+
 	return nil
 }
 
-func compileArticle(articleFile string) error {
-	name := trimExtension(articleFile)
+// assetTaskForSource returns the single task that reproduces just the
+// output(s) built from source, if source is one of the non-page asset roots
+// buildTasks knows about (the fonts directory, a single image, or the
+// javascripts/stylesheets directory). ok is false if source isn't one of
+// these, in which case the caller should fall back to a full rebuild.
+//
+// Note this can't help when modules are in play: rootDir re-materializes a
+// fresh temporary directory on every call, so source (captured from a
+// previous rebuild) will never again equal what rootDir returns here - that
+// case always falls through to ok == false, which is safe, just not
+// incremental.
+func assetTaskForSource(source string) (task *pool.Task, ok bool, err error) {
+	fontsDir, err := rootDir("content/fonts", path.Join(singularity.ContentDir, "fonts"))
+	if err != nil {
+		return nil, false, err
+	}
+	fontsSource, err := filepath.Abs(fontsDir)
+	if err != nil {
+		return nil, false, err
+	}
+	if source == fontsSource {
+		return pool.NewTask(func() error { return linkFonts(fontsSource) }), true, nil
+	}
+
+	imagesDir, err := rootDir("content/images", singularity.ContentDir+"/images")
+	if err != nil {
+		return nil, false, err
+	}
+	imagesSource, err := filepath.Abs(imagesDir)
+	if err != nil {
+		return nil, false, err
+	}
+	if filepath.Dir(source) == imagesSource {
+		return imageTask(imagesDir, filepath.Base(source)), true, nil
+	}
+
+	javascriptsDir, err := rootDir("content/javascripts", path.Join(singularity.ContentDir, "javascripts"))
+	if err != nil {
+		return nil, false, err
+	}
+	if source == javascriptsDir {
+		task, err := javascriptsTask()
+		return task, true, err
+	}
+
+	stylesheetsDir, err := rootDir("content/stylesheets", path.Join(singularity.ContentDir, "stylesheets"))
+	if err != nil {
+		return nil, false, err
+	}
+	if source == stylesheetsDir {
+		task, err := stylesheetsTask()
+		return task, true, err
+	}
+
+	return nil, false, nil
+}
+
+// compileArticle renders a single page and returns the path (relative to
+// TargetDir) it was written to, so that callers can record it against the
+// dependency graph.
+func compileArticle(ctx context.Context, page content.Page) (string, error) {
+	name := page.Slug()
 	log.Debugf("Rendering article: %v", name)
 
-	source, err :=
-		os.ReadFile(path.Join(singularity.ContentDir, "articles", articleFile))
+	if pather, ok := page.(content.Pather); ok {
+		deps.Collect(ctx, deps.KindSource, pather.Path())
+	}
+
+	source, err := page.Body()
 	if err != nil {
-		return err
+		return "", err
+	}
+	hash := memcache.Hash(source)
+	out := page.OutputPath()
+
+	rendered, err := memcache.GetOrCompute(memcache.Default,
+		memcache.Key{Kind: memcache.KindMarkdown, Path: out, ContentHash: hash},
+		memcache.StringSize,
+		func() (string, error) {
+			return markdown.Render(string(source), nil)
+		})
+	if err != nil {
+		return "", err
 	}
-	rendered := markdown.Render(string(source), nil)
 
-	tocContent, err := toc.Render(rendered)
+	// toc.Render is a pure function of rendered, which is itself derived
+	// from page's own source - already collected above - so there's nothing
+	// further for it to report against ctx.
+	tocContent, err := memcache.GetOrCompute(memcache.Default,
+		memcache.Key{Kind: memcache.KindTOC, Path: out, ContentHash: hash},
+		memcache.StringSize,
+		func() (string, error) {
+			return toc.Render(rendered)
+		})
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	locals := getLocals(name, map[string]interface{}{
+	locals := getLocals(page, map[string]interface{}{
 		"Content": rendered,
 		"TOC":     tocContent,
 	})
 
-	// Give index files an .html extension so that they'll be served locally
-	// from directory-level requests instead of a directory listing.
-	out := name
-	if name == "index" {
-		out = name + ".html"
-	}
-
-	err = renderView(singularity.MainLayout,
+	err = renderView(ctx, singularity.MainLayout,
 		path.Join(singularity.LayoutsDir, "article"),
 		path.Join(singularity.TargetDir, out), locals)
 	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+// renderAliasTarget writes a small HTML stub at
+// TargetDir/<alias>/index.html that redirects (via a meta refresh, plus a
+// canonical link for search engines) to page's real URL. It's how an old
+// article URL keeps working after the article itself has moved or been
+// renamed.
+func renderAliasTarget(ctx context.Context, page content.Page, alias string) error {
+	log.Debugf("Rendering alias: %v -> %v", alias, page.OutputPath())
+
+	if pather, ok := page.(content.Pather); ok {
+		deps.Collect(ctx, deps.KindSource, pather.Path())
+	}
+
+	dir := path.Join(singularity.TargetDir, alias)
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
-	return nil
+	locals := getLocals(page, map[string]interface{}{
+		"Target": pageURL(page),
+	})
+
+	return renderView(ctx, singularity.MainLayout,
+		path.Join(singularity.LayoutsDir, "alias"),
+		path.Join(dir, "index.html"), locals)
+}
+
+// pageURL is the absolute, site-rooted URL a page is served at.
+func pageURL(page content.Page) string {
+	out := strings.TrimSuffix(page.OutputPath(), "index.html")
+	out = strings.TrimSuffix(out, ".html")
+	return "/" + out
 }
 
 //
@@ -212,29 +554,108 @@ func compileArticle(articleFile string) error {
 // resources.
 //
 
-func tasksForArticles() ([]*pool.Task, error) {
-	files, err := os.ReadDir(path.Join(singularity.ContentDir, "articles"))
+// articlePages returns every Page known to any registered content.Source:
+// the articles directory (resolved through moduleFS exactly as the other
+// root directories are), plus one content.JSONFeedSource per entry in
+// conf.ArticleFeeds.
+func articlePages() ([]content.Page, error) {
+	articlesDir, err := rootDir("articles", path.Join(singularity.ContentDir, "articles"))
 	if err != nil {
 		return nil, err
 	}
 
+	sources := []content.Source{content.NewFrontMatterSource(articlesDir)}
+	for _, location := range conf.ArticleFeeds {
+		sources = append(sources, content.NewJSONFeedSource(location))
+	}
+
+	var pages []content.Page
+	for _, source := range sources {
+		sourcePages, err := source.Pages()
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, sourcePages...)
+	}
+
+	return pages, nil
+}
+
+func tasksForArticles(pages []content.Page) []*pool.Task {
 	var tasks []*pool.Task
-	for _, fileInfo := range files {
-		// be careful with closures in loops
-		name := fileInfo.Name()
+	for _, page := range pages {
+		if page.Draft() {
+			continue
+		}
+
+		tasks = append(tasks, articleTask(page))
+	}
+
+	return tasks
+}
+
+// articleTask builds the pool.Task that compiles a single page, recording
+// its dependencies against depsGraph once it succeeds.
+func articleTask(page content.Page) *pool.Task {
+	return pool.NewTask(func() error {
+		set := deps.NewDepSet()
+		ctx := deps.WithCollector(context.Background(), set)
+
+		out, err := compileArticle(ctx, page)
+		if err != nil {
+			return err
+		}
+
+		depsGraph.Record(out, page.Slug(), set)
+		return nil
+	})
+}
+
+// tasksForAliases builds the pool.Tasks that write a redirect stub for every
+// alias declared across pages, after checking that no two pages declare the
+// same one (which would mean one silently overwriting the other's stub).
+// Stale aliases from a prior build that no page declares anymore are
+// cleaned up the same way every other stale output is: CreateOutputDirs
+// starts each full build from an empty TargetDir.
+func tasksForAliases(pages []content.Page) ([]*pool.Task, error) {
+	owner := make(map[string]string)
 
-		if isHidden(name) {
+	var tasks []*pool.Task
+	for _, page := range pages {
+		if page.Draft() {
 			continue
 		}
 
-		tasks = append(tasks, pool.NewTask(func() error {
-			return compileArticle(name)
-		}))
+		for _, alias := range page.Aliases() {
+			if existing, ok := owner[alias]; ok {
+				return nil, fmt.Errorf("alias %q is declared by both %q and %q", alias, existing, page.Slug())
+			}
+			owner[alias] = page.Slug()
+
+			tasks = append(tasks, aliasTask(page, alias))
+		}
 	}
 
 	return tasks, nil
 }
 
+// aliasTask builds the pool.Task that writes a single alias's redirect
+// stub, recording its dependencies against depsGraph once it succeeds, the
+// same way articleTask does for an article.
+func aliasTask(page content.Page, alias string) *pool.Task {
+	return pool.NewTask(func() error {
+		set := deps.NewDepSet()
+		ctx := deps.WithCollector(context.Background(), set)
+
+		if err := renderAliasTarget(ctx, page, alias); err != nil {
+			return err
+		}
+
+		depsGraph.Record(path.Join(alias, "index.html"), page.Slug(), set)
+		return nil
+	})
+}
+
 //
 // Other functions
 //
@@ -286,14 +707,18 @@ create:
 }
 
 // Gets a map of local values for use while rendering a template and includes
-// a few "special" values that are globally relevant to all templates.
-func getLocals(title string, locals map[string]interface{}) map[string]interface{} {
+// a few "special" values that are globally relevant to all templates. Page
+// is included as-is so that templates can reach through it for anything
+// compileArticle didn't already promote into locals (e.g. .Page.PublishedAt,
+// .Page.Meta.foo).
+func getLocals(page content.Page, locals map[string]interface{}) map[string]interface{} {
 	defaults := map[string]interface{}{
 		"GoogleAnalyticsID": conf.GoogleAnalyticsID,
 		"LocalFonts":        conf.LocalFonts,
 		"Release":           singularity.Release,
-		"Title":             title,
+		"Title":             page.Title(),
 		"ViewportWidth":     "device-width",
+		"Page":              page,
 	}
 
 	for k, v := range locals {
@@ -307,10 +732,74 @@ func isHidden(file string) bool {
 	return strings.HasPrefix(file, ".")
 }
 
-func renderView(layout, view, target string, locals map[string]interface{}) error {
+// rootDir returns the directory to read the named standard root (see
+// modules.StandardRoots) from. With no singularity.mod present, that's just
+// fallback, exactly as it's always been; with one present, it's a temporary
+// directory containing the merged view of the project and every module it
+// imports, project files winning.
+func rootDir(root, fallback string) (string, error) {
+	if moduleFS == nil {
+		return fallback, nil
+	}
+
+	return moduleFS.Materialize(root)
+}
+
+// resolveViewPath resolves a logical layout/view path (e.g. "layouts/main")
+// through moduleFS, so that a project can override just one layout file
+// while inheriting the rest from an imported theme module. With no
+// singularity.mod present, or if the path can't be found in any layer, it's
+// returned unchanged and left for ace.Load to report on.
+func resolveViewPath(logicalPath string) string {
+	if moduleFS == nil {
+		return logicalPath
+	}
+
+	resolved, err := moduleFS.Resolve(logicalPath + ".ace")
+	if err != nil {
+		return logicalPath
+	}
+
+	return strings.TrimSuffix(resolved, ".ace")
+}
+
+// templateSize is a rough, fixed size estimate for a compiled ace template,
+// used to account for it in the cache's byte budget. A compiled template
+// doesn't expose anything cheaper to measure than walking its whole AST, so
+// an estimate is good enough here: being off by a constant factor just
+// shifts how many templates the cache holds before evicting, not whether it
+// behaves correctly.
+const templateSize = 8 << 10 // 8 KiB
+
+func templateSizeOf(*ace.Template) int64 {
+	return templateSize
+}
+
+func renderView(ctx context.Context, layout, view, target string, locals map[string]interface{}) error {
 	log.Debugf("Rendering: %v", target)
 
-	template, err := ace.Load(layout, view, &ace.Options{FuncMap: templatehelpers.FuncMap})
+	resolvedLayout := resolveViewPath(layout)
+	resolvedView := resolveViewPath(view)
+	layoutFile := resolvedLayout + ".ace"
+	viewFile := resolvedView + ".ace"
+	deps.Collect(ctx, deps.KindLayout, layoutFile)
+	deps.Collect(ctx, deps.KindLayout, viewFile)
+
+	// Stat-based rather than content-based hashing here: templates are
+	// loaded on every single render but edited rarely, so hashing their
+	// full content (including every partial ace.Load pulls in) on every
+	// lookup would cost more than the cache saves.
+	hash, err := memcache.StatHash(layoutFile, viewFile)
+	if err != nil {
+		return err
+	}
+
+	template, err := memcache.GetOrCompute(memcache.Default,
+		memcache.Key{Kind: memcache.KindTemplate, Path: layoutFile + "+" + viewFile, ContentHash: hash},
+		templateSizeOf,
+		func() (*ace.Template, error) {
+			return ace.Load(resolvedLayout, resolvedView, &ace.Options{FuncMap: templatehelpers.FuncMap})
+		})
 	if err != nil {
 		return err
 	}
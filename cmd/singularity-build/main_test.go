@@ -5,12 +5,28 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	singularity "github.com/jmyers/brandur-singularity"
 	"github.com/jmyers/brandur-singularity/pool"
 	assert "github.com/stretchr/testify/require"
 )
 
+// fakePage is a minimal content.Page used to exercise getLocals without
+// pulling in a real content source.
+type fakePage struct {
+	title string
+}
+
+func (p fakePage) Slug() string                     { return "fake" }
+func (p fakePage) Title() string                    { return p.title }
+func (p fakePage) PublishedAt() time.Time           { return time.Time{} }
+func (p fakePage) Draft() bool                      { return false }
+func (p fakePage) Aliases() []string                { return nil }
+func (p fakePage) Meta() map[string]interface{}     { return nil }
+func (p fakePage) OutputPath() string               { return "fake" }
+func (p fakePage) Body() ([]byte, error)            { return nil, nil }
+
 func TestEnsureSymlink(t *testing.T) {
 	dir, err := os.MkdirTemp("", "symlink")
 	assert.NoError(t, err)
@@ -62,13 +78,16 @@ func TestEnsureSymlink(t *testing.T) {
 }
 
 func TestGetLocals(t *testing.T) {
-	locals := getLocals("Title", map[string]interface{}{
+	page := fakePage{title: "Title"}
+
+	locals := getLocals(page, map[string]interface{}{
 		"Foo": "Bar",
 	})
 
 	assert.Equal(t, "Bar", locals["Foo"])
 	assert.Equal(t, singularity.Release, locals["Release"])
 	assert.Equal(t, "Title", locals["Title"])
+	assert.Equal(t, page, locals["Page"])
 }
 
 func TestIsHidden(t *testing.T) {
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmyers/brandur-singularity/modules"
+)
+
+// runMod dispatches the `mod` subcommand's own subcommands: init, get,
+// graph, and vendor.
+func runMod(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: singularity-build mod <init|get|graph|vendor> ...")
+	}
+
+	switch args[0] {
+	case "init":
+		return runModInit(args[1:])
+	case "get":
+		return runModGet(args[1:])
+	case "graph":
+		return runModGraph(args[1:])
+	case "vendor":
+		return runModVendor(args[1:])
+	default:
+		return fmt.Errorf("unknown mod subcommand: %v", args[0])
+	}
+}
+
+// runModInit creates a new, empty singularity.mod declaring the project's
+// own module path (only meaningful if this project is later imported as a
+// module by some other site).
+func runModInit(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: singularity-build mod init <module-path>")
+	}
+
+	if _, err := os.Stat(modules.ManifestFile); err == nil {
+		return fmt.Errorf("%s already exists", modules.ManifestFile)
+	}
+
+	manifest := &modules.Manifest{Module: args[0]}
+	if err := modules.SaveManifest(modules.ManifestFile, manifest); err != nil {
+		return err
+	}
+
+	log.Infof("Created %s for module %s", modules.ManifestFile, args[0])
+	return nil
+}
+
+// runModGet adds (or updates) a requirement in singularity.mod and fetches
+// it so the cache is warm before the next build.
+//
+//	singularity-build mod get github.com/brandur/singularity-theme-paper v1.2.0 \
+//		layouts:layouts content/images:content/images
+func runModGet(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: singularity-build mod get <path> [version] [source:target ...]")
+	}
+
+	req := modules.Requirement{Path: args[0]}
+	rest := args[1:]
+
+	if len(rest) > 0 && !strings.Contains(rest[0], ":") {
+		req.Version = rest[0]
+		rest = rest[1:]
+	}
+
+	for _, m := range rest {
+		parts := strings.SplitN(m, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid mount %q, expected source:target", m)
+		}
+		req.Mounts = append(req.Mounts, modules.Mount{Source: parts[0], Target: parts[1]})
+	}
+
+	manifest, err := modules.LoadManifest(modules.ManifestFile)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		manifest = &modules.Manifest{}
+	}
+
+	replaced := false
+	for i, existing := range manifest.Requires {
+		if existing.Path == req.Path {
+			manifest.Requires[i] = req
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		manifest.Requires = append(manifest.Requires, req)
+	}
+
+	if !req.IsLocal() {
+		if _, err := modules.Dir(req, "."); err != nil {
+			return err
+		}
+	}
+
+	if err := modules.SaveManifest(modules.ManifestFile, manifest); err != nil {
+		return err
+	}
+
+	log.Infof("Added %s to %s", req.Path, modules.ManifestFile)
+	return nil
+}
+
+// runModGraph prints the resolved mount edges for every required module.
+func runModGraph(args []string) error {
+	manifest, err := modules.LoadManifest(modules.ManifestFile)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return fmt.Errorf("no %s in this directory", modules.ManifestFile)
+	}
+
+	return modules.PrintGraph(os.Stdout, manifest)
+}
+
+// runModVendor copies every file in the merged view of each standard
+// component root - the project's own files as well as every imported
+// module's mounted files - into a local vendor/ directory mirroring those
+// roots. Nothing reads vendor/ back: a build still resolves every
+// requirement through modules.Dir, fetching remote modules into the cache.
+func runModVendor(args []string) error {
+	if moduleFS == nil {
+		return fmt.Errorf("no %s in this directory", modules.ManifestFile)
+	}
+
+	for _, root := range modules.StandardRoots {
+		entries, err := moduleFS.ReadDir(root)
+		if err != nil {
+			return err
+		}
+
+		destDir := "vendor/" + root
+		if err := os.MkdirAll(destDir, 0755); err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err := copyFile(entry.Path, destDir+"/"+entry.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Info("Vendored imported modules into vendor/")
+	return nil
+}
+
+func copyFile(source, dest string) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(dest, data, 0644)
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/fsnotify/fsnotify"
+	singularity "github.com/jmyers/brandur-singularity"
+	"github.com/jmyers/brandur-singularity/cache/memcache"
+	"github.com/jmyers/brandur-singularity/content"
+	"github.com/jmyers/brandur-singularity/herrors"
+	"github.com/jmyers/brandur-singularity/pool"
+	"github.com/yosssi/ace"
+	"golang.org/x/net/websocket"
+)
+
+// rssCheckInterval is how often runServe checks the process's live RSS
+// against memcache.Default's budget; see memcache.Cache.WatchRSS.
+const rssCheckInterval = 30 * time.Second
+
+// reloadScript is injected at the end of every HTML page served in `serve`
+// mode. It opens a WebSocket back to the dev server and reloads the page
+// whenever a rebuild finishes, hugo-server style.
+const reloadScript = `
+<script>
+(function() {
+	var socket = new WebSocket("ws://" + window.location.host + "/__singularity__/reload");
+	socket.onmessage = function() { window.location.reload(); };
+	socket.onclose = function() { setTimeout(function() { window.location.reload(); }, 1000); };
+})();
+</script>
+`
+
+// errorOverlayTemplate renders a build error (recovered via herrors) as a
+// full-page HTML overlay: the failing file, the line it failed on, and a
+// source excerpt around it.
+var errorOverlayTemplate = template.Must(template.New("error-overlay").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Build error</title>
+<style>
+  body { background: #1e1e1e; color: #ddd; font-family: monospace; padding: 2em; }
+  h1 { color: #f66; font-size: 1.2em; }
+  .location { color: #999; margin-bottom: 1em; }
+  .excerpt { background: #2a2a2a; border-radius: 4px; padding: 0.5em 0; }
+  .excerpt div { padding: 0 1em; white-space: pre; }
+  .excerpt .current { background: #4a2020; }
+  .excerpt .number { color: #777; display: inline-block; width: 3em; text-align: right; margin-right: 1em; }
+  .message { margin-top: 1em; }
+</style>
+</head>
+<body>
+<h1>Build failed</h1>
+<div class="location">{{.File}}:{{.Line}}{{if .Col}}:{{.Col}}{{end}}</div>
+<div class="excerpt">{{range .Excerpt}}<div{{if .Current}} class="current"{{end}}><span class="number">{{.Number}}</span>{{.Text}}</div>
+{{end}}</div>
+<div class="message">{{.Message}}</div>
+</body>
+</html>
+`))
+
+// devServer is the state backing the `serve` command: the last build result
+// (used to render the error overlay), and the set of browsers currently
+// holding a live-reload WebSocket open.
+type devServer struct {
+	disableOverlay bool
+
+	// force disables the dependency graph entirely: every rebuild recompiles
+	// everything, which is slower but a useful escape hatch if the graph is
+	// ever suspected of being stale or wrong.
+	force bool
+
+	// showStats logs memcache.Default's hit/miss counters after every
+	// rebuild, mirroring the `build --stats` flag.
+	showStats bool
+
+	mu       sync.RWMutex
+	buildErr error
+
+	clientsMu sync.Mutex
+	clients   map[*websocket.Conn]bool
+}
+
+// runServe builds the site once, then starts an HTTP server over TargetDir
+// and watches ContentDir/LayoutsDir for changes, rebuilding and notifying
+// connected browsers whenever something changes.
+func runServe() error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	disableOverlay := fs.Bool("disable-browser-error", conf.DisableBrowserError,
+		"Print build errors to the console instead of showing an in-browser overlay")
+	force := fs.Bool("force", false,
+		"Rebuild everything on every change instead of using the dependency graph")
+	showStats := fs.Bool("stats", false,
+		"Log memcache hit/miss stats after every rebuild")
+	if err := fs.Parse(argsAfterCommand()); err != nil {
+		return err
+	}
+
+	s := &devServer{
+		disableOverlay: *disableOverlay,
+		force:          *force,
+		showStats:      *showStats,
+		clients:        make(map[*websocket.Conn]bool),
+	}
+	s.rebuild(nil)
+
+	// serve is the only long-running command: build exits as soon as it's
+	// done, so only serve needs a safety net against the budget having been
+	// sized too optimistically.
+	stopWatchingRSS := memcache.Default.WatchRSS(rssCheckInterval)
+	defer stopWatchingRSS()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, dir := range []string{singularity.ContentDir, singularity.LayoutsDir} {
+		if err := watchRecursive(watcher, dir); err != nil {
+			return err
+		}
+	}
+
+	go s.watchLoop(watcher)
+
+	mux := http.NewServeMux()
+	mux.Handle("/__singularity__/reload", websocket.Handler(s.handleReload))
+	mux.Handle("/", s.handleRequest())
+
+	log.Infof("Serving %v on %v", singularity.TargetDir, conf.ServeAddr)
+	return http.ListenAndServe(conf.ServeAddr, mux)
+}
+
+// argsAfterCommand returns the CLI arguments following the subcommand name
+// (e.g. for `singularity-build serve --disable-browser-error`, that's just
+// `[--disable-browser-error]`).
+func argsAfterCommand() []string {
+	if len(os.Args) > 2 {
+		return os.Args[2:]
+	}
+	return nil
+}
+
+// rebuild reruns whatever subset of the build changed warrants (see
+// tasksFor), recording the first error encountered (if any) so that it can
+// be displayed by the error overlay, then pings every connected browser to
+// reload. changed is nil for the initial build on startup, which always
+// builds everything.
+func (s *devServer) rebuild(changed map[string]bool) {
+	start := time.Now()
+
+	// ace.Load caches every compiled template in a package-global cache
+	// keyed on its paths, and keeps serving the cached copy forever unless
+	// told otherwise - our own memcache-backed cache in renderView only
+	// decides whether to call ace.Load again at all, so a stale entry
+	// behind it would never get recompiled. Flushing here, before anything
+	// below can call ace.Load, means a real file edit always gets a real
+	// recompile.
+	ace.FlushCache()
+
+	tasks, err := s.tasksFor(changed)
+	if err == nil {
+		p := pool.NewPool(tasks, conf.Concurrency)
+		p.Run()
+		err = firstTaskError(p.Tasks)
+
+		if saveErr := depsGraph.Save(depsGraphPath); saveErr != nil {
+			log.Error(saveErr)
+		}
+	}
+
+	s.mu.Lock()
+	s.buildErr = err
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Errorf("Build failed: %v", err)
+	} else {
+		log.Infof("Rebuilt site in %v.", time.Since(start))
+	}
+
+	if s.showStats {
+		log.Info(memcache.Default.Stats())
+	}
+
+	s.notifyClients()
+}
+
+// tasksFor decides how much of the build actually needs to rerun. On the
+// first build, with --force, or whenever the dependency graph can't account
+// for every output a changed file might affect, it falls back to a full
+// rebuild; otherwise it reruns just the article and asset tasks whose
+// outputs' dependencies changed.
+func (s *devServer) tasksFor(changed map[string]bool) ([]*pool.Task, error) {
+	if s.force || changed == nil {
+		return buildTasks()
+	}
+
+	affected := depsGraph.AffectedOutputs(changed)
+	if affected == nil {
+		// Nothing in the graph references any of the changed paths - most
+		// likely a new file was added, which the graph has no way to know
+		// about yet. Rebuild everything to be safe.
+		return buildTasks()
+	}
+
+	sources := depsGraph.Sources(affected)
+	if len(sources) != len(affected) {
+		// At least one affected output doesn't map back to a task we know
+		// how to rerun on its own (e.g. a page, once those are tracked
+		// too); fall back rather than risk leaving it stale.
+		return buildTasks()
+	}
+
+	pages, err := articlePages()
+	if err != nil {
+		return nil, err
+	}
+
+	pagesBySlug := make(map[string]content.Page, len(pages))
+	for _, page := range pages {
+		pagesBySlug[page.Slug()] = page
+	}
+
+	var tasks []*pool.Task
+	for _, source := range sources {
+		if page, ok := pagesBySlug[source]; ok {
+			if len(page.Aliases()) > 0 || hadRecordedAlias(page) {
+				// Rerunning just articleTask would leave the page's alias
+				// stub(s) out of date - and if every alias was removed from
+				// front matter entirely, the old stub(s) would be left
+				// behind forever, since only a full rebuild recreates
+				// TargetDir from scratch. Fall back rather than track alias
+				// add/remove/rename through the graph too.
+				return buildTasks()
+			}
+
+			tasks = append(tasks, articleTask(page))
+			continue
+		}
+
+		assetTask, ok, err := assetTaskForSource(source)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			// Doesn't match a page or a known asset root - most likely a
+			// page that was renamed or removed, or a module source whose
+			// materialized path has changed since it was recorded. Fall
+			// back rather than risk leaving a stale output around.
+			return buildTasks()
+		}
+		tasks = append(tasks, assetTask)
+	}
+
+	log.Infof("Rebuilding %d of %d output(s) via dependency graph.", len(tasks), len(affected))
+	return tasks, nil
+}
+
+// hadRecordedAlias reports whether depsGraph remembers any output - other
+// than the page's own article output - attributed to page's source, which
+// means a prior build wrote an alias stub for it that a targeted
+// articleTask rerun wouldn't know to clean up if page no longer declares
+// any aliases.
+func hadRecordedAlias(page content.Page) bool {
+	for output, info := range depsGraph.Outputs {
+		if info.Source == page.Slug() && output != page.OutputPath() {
+			return true
+		}
+	}
+	return false
+}
+
+func firstTaskError(tasks []*pool.Task) error {
+	for _, task := range tasks {
+		if task.Err != nil {
+			return task.Err
+		}
+	}
+	return nil
+}
+
+// watchLoop rebuilds (after a short debounce, since editors tend to fire
+// several events per save) whenever a watched file changes, passing along
+// every path that changed during the debounce window so rebuild can consult
+// the dependency graph.
+func (s *devServer) watchLoop(watcher *fsnotify.Watcher) {
+	var mu sync.Mutex
+	var timer *time.Timer
+	pending := make(map[string]bool)
+
+	flush := func() {
+		mu.Lock()
+		changed := pending
+		pending = make(map[string]bool)
+		mu.Unlock()
+
+		s.rebuild(changed)
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+
+			mu.Lock()
+			pending[event.Name] = true
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(100*time.Millisecond, flush)
+			mu.Unlock()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Error(err)
+		}
+	}
+}
+
+// watchRecursive adds root and every directory beneath it to watcher.
+// fsnotify doesn't support recursive watches natively, so new
+// subdirectories created after `serve` starts won't be picked up, which is
+// an acceptable limitation for a dev server.
+func watchRecursive(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(p)
+		}
+		return nil
+	})
+}
+
+// handleRequest serves TargetDir, intercepting every request with the error
+// overlay while the last build is broken, and otherwise injecting the
+// live-reload script into HTML responses.
+func (s *devServer) handleRequest() http.Handler {
+	fileServer := http.FileServer(http.Dir(singularity.TargetDir))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		buildErr := s.buildErr
+		s.mu.RUnlock()
+
+		if buildErr != nil && !s.disableOverlay {
+			renderErrorOverlay(w, buildErr)
+			return
+		}
+
+		serveWithReloadScript(w, r, fileServer)
+	})
+}
+
+// serveWithReloadScript runs the request through fileServer and, if the
+// response looks like an HTML document, splices reloadScript in before
+// </body>.
+func serveWithReloadScript(w http.ResponseWriter, r *http.Request, fileServer http.Handler) {
+	rec := httptest.NewRecorder()
+	fileServer.ServeHTTP(rec, r)
+
+	header := w.Header()
+	for k, v := range rec.Header() {
+		header[k] = v
+	}
+
+	body := rec.Body.Bytes()
+	if rec.Code == http.StatusOK && bytes.Contains(body, []byte("</body>")) {
+		body = bytes.Replace(body, []byte("</body>"), []byte(reloadScript+"</body>"), 1)
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+
+	w.WriteHeader(rec.Code)
+	w.Write(body)
+}
+
+// renderErrorOverlay writes an HTML page describing buildErr: the failing
+// file/line and a source excerpt where herrors can recover one, or just the
+// raw error message otherwise.
+func renderErrorOverlay(w http.ResponseWriter, buildErr error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+
+	sourceErr, ok := herrors.Parse(buildErr)
+	if !ok {
+		fmt.Fprintf(w, "<!DOCTYPE html><html><body><h1>Build failed</h1><pre>%s</pre></body></html>",
+			template.HTMLEscapeString(buildErr.Error()))
+		return
+	}
+
+	if err := errorOverlayTemplate.Execute(w, sourceErr); err != nil {
+		log.Error(err)
+	}
+}
+
+// handleReload registers ws as a client to be pinged on the next rebuild and
+// blocks until it disconnects.
+func (s *devServer) handleReload(ws *websocket.Conn) {
+	s.clientsMu.Lock()
+	s.clients[ws] = true
+	s.clientsMu.Unlock()
+
+	defer func() {
+		s.clientsMu.Lock()
+		delete(s.clients, ws)
+		s.clientsMu.Unlock()
+	}()
+
+	// We only ever write to this connection (from notifyClients); block here
+	// reading until the browser closes it.
+	var buf [1]byte
+	for {
+		if _, err := ws.Read(buf[:]); err != nil {
+			return
+		}
+	}
+}
+
+// notifyClients pings every browser with an open live-reload connection so
+// that it reloads the page.
+func (s *devServer) notifyClients() {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	for ws := range s.clients {
+		if _, err := ws.Write([]byte("reload")); err != nil {
+			ws.Close()
+			delete(s.clients, ws)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package content
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileSystemSource yields one Page per non-hidden file in Dir, treating the
+// entire file as the page's body and its name (sans extension) as both its
+// slug and its title. This is the behavior singularity has always had for
+// articles, from before any metadata format existed.
+type FileSystemSource struct {
+	Dir string
+}
+
+// NewFileSystemSource returns a FileSystemSource reading from dir.
+func NewFileSystemSource(dir string) *FileSystemSource {
+	return &FileSystemSource{Dir: dir}
+}
+
+func (s *FileSystemSource) Pages() ([]Page, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []Page
+	for _, entry := range entries {
+		if isHidden(entry.Name()) {
+			continue
+		}
+
+		pages = append(pages, &filePage{
+			slug: trimExtension(entry.Name()),
+			path: path.Join(s.Dir, entry.Name()),
+		})
+	}
+
+	return pages, nil
+}
+
+// filePage is the Page backing a single file read by FileSystemSource.
+type filePage struct {
+	slug string
+	path string
+}
+
+func (p *filePage) Slug() string                { return p.slug }
+func (p *filePage) Title() string               { return p.slug }
+func (p *filePage) PublishedAt() time.Time      { return time.Time{} }
+func (p *filePage) Draft() bool                 { return false }
+func (p *filePage) Aliases() []string           { return nil }
+func (p *filePage) Meta() map[string]interface{} { return nil }
+func (p *filePage) OutputPath() string          { return outputPath(p.slug) }
+func (p *filePage) Path() string                { return p.path }
+func (p *filePage) Body() ([]byte, error)       { return os.ReadFile(p.path) }
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+func trimExtension(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
@@ -0,0 +1,168 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterDelim is the line that opens and closes a front matter block.
+const frontMatterDelim = "---"
+
+// FrontMatterSource is a FileSystemSource that additionally looks for a YAML
+// front matter block - delimited by a pair of "---" lines - at the top of
+// each file, and uses it to fill in Title, PublishedAt, Draft, and Aliases.
+// A file with no front matter behaves exactly like FileSystemSource: its
+// whole content is the body, and its slug doubles as its title.
+type FrontMatterSource struct {
+	Dir string
+}
+
+// NewFrontMatterSource returns a FrontMatterSource reading from dir.
+func NewFrontMatterSource(dir string) *FrontMatterSource {
+	return &FrontMatterSource{Dir: dir}
+}
+
+func (s *FrontMatterSource) Pages() ([]Page, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []Page
+	for _, entry := range entries {
+		if isHidden(entry.Name()) {
+			continue
+		}
+
+		filePath := path.Join(s.Dir, entry.Name())
+
+		source, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := newFrontMatterPage(trimExtension(entry.Name()), filePath, source)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+
+		pages = append(pages, page)
+	}
+
+	return pages, nil
+}
+
+// frontMatterPage is the Page backing a single file read by
+// FrontMatterSource.
+type frontMatterPage struct {
+	slug    string
+	path    string
+	title   string
+	date    time.Time
+	draft   bool
+	aliases []string
+	meta    map[string]interface{}
+	body    []byte
+}
+
+// knownFrontMatter is the set of front matter fields singularity understands
+// directly; everything else in the block is preserved as Meta instead of
+// being dropped.
+type knownFrontMatter struct {
+	Title   string   `yaml:"title"`
+	Date    string   `yaml:"date"`
+	Draft   bool     `yaml:"draft"`
+	Aliases []string `yaml:"aliases"`
+}
+
+func newFrontMatterPage(slug, path string, source []byte) (*frontMatterPage, error) {
+	page := &frontMatterPage{slug: slug, path: path, title: slug, body: source}
+
+	block, body, ok := splitFrontMatter(source)
+	if !ok {
+		return page, nil
+	}
+	page.body = body
+
+	var known knownFrontMatter
+	if err := yaml.Unmarshal(block, &known); err != nil {
+		return nil, err
+	}
+
+	meta := make(map[string]interface{})
+	if err := yaml.Unmarshal(block, &meta); err != nil {
+		return nil, err
+	}
+	delete(meta, "title")
+	delete(meta, "date")
+	delete(meta, "draft")
+	delete(meta, "aliases")
+
+	if known.Title != "" {
+		page.title = known.Title
+	}
+
+	if known.Date != "" {
+		date, err := parseFrontMatterDate(known.Date)
+		if err != nil {
+			return nil, err
+		}
+		page.date = date
+	}
+
+	page.draft = known.Draft
+	page.aliases = known.Aliases
+
+	if len(meta) > 0 {
+		page.meta = meta
+	}
+
+	return page, nil
+}
+
+// splitFrontMatter splits source into its front matter block (the bytes
+// between the two "---" delimiter lines) and its body (everything after the
+// closing delimiter). ok is false if source doesn't open with a front matter
+// block, in which case body is just source unchanged.
+func splitFrontMatter(source []byte) (block []byte, body []byte, ok bool) {
+	lines := bytes.SplitAfter(source, []byte("\n"))
+	if len(lines) == 0 || strings.TrimSpace(string(lines[0])) != frontMatterDelim {
+		return nil, source, false
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(string(lines[i])) == frontMatterDelim {
+			return bytes.Join(lines[1:i], nil), bytes.Join(lines[i+1:], nil), true
+		}
+	}
+
+	return nil, source, false
+}
+
+// parseFrontMatterDate parses the handful of date formats front matter
+// commonly shows up in.
+func parseFrontMatterDate(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date format: %q", value)
+}
+
+func (p *frontMatterPage) Slug() string                { return p.slug }
+func (p *frontMatterPage) Title() string               { return p.title }
+func (p *frontMatterPage) PublishedAt() time.Time      { return p.date }
+func (p *frontMatterPage) Draft() bool                 { return p.draft }
+func (p *frontMatterPage) Aliases() []string           { return p.aliases }
+func (p *frontMatterPage) Meta() map[string]interface{} { return p.meta }
+func (p *frontMatterPage) OutputPath() string          { return outputPath(p.slug) }
+func (p *frontMatterPage) Path() string                { return p.path }
+func (p *frontMatterPage) Body() ([]byte, error)       { return p.body, nil }
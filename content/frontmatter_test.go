@@ -0,0 +1,77 @@
+package content
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func writeArticle(t *testing.T, dir, name, body string) {
+	t.Helper()
+	assert.NoError(t, os.WriteFile(path.Join(dir, name), []byte(body), 0644))
+}
+
+func TestFrontMatterSourcePages(t *testing.T) {
+	dir := t.TempDir()
+
+	writeArticle(t, dir, "with-front-matter.md", `---
+title: Hello, World
+date: 2023-05-01
+draft: true
+aliases:
+  - old-hello
+  - ancient-hello
+category: announcements
+---
+# Hello
+
+Body content.
+`)
+
+	writeArticle(t, dir, "plain.md", "# Just a file\n\nNo front matter here.\n")
+	writeArticle(t, dir, ".gitkeep", "")
+
+	pages, err := NewFrontMatterSource(dir).Pages()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pages))
+
+	byslug := make(map[string]Page)
+	for _, page := range pages {
+		byslug[page.Slug()] = page
+	}
+
+	withFrontMatter := byslug["with-front-matter"]
+	assert.Equal(t, "Hello, World", withFrontMatter.Title())
+	assert.Equal(t, time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), withFrontMatter.PublishedAt())
+	assert.True(t, withFrontMatter.Draft())
+	assert.Equal(t, []string{"old-hello", "ancient-hello"}, withFrontMatter.Aliases())
+	assert.Equal(t, "announcements", withFrontMatter.Meta()["category"])
+
+	body, err := withFrontMatter.Body()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "title:")
+	assert.Contains(t, string(body), "# Hello")
+
+	plain := byslug["plain"]
+	assert.Equal(t, "plain", plain.Title())
+	assert.False(t, plain.Draft())
+	assert.Nil(t, plain.Meta())
+
+	plainBody, err := plain.Body()
+	assert.NoError(t, err)
+	assert.Equal(t, "# Just a file\n\nNo front matter here.\n", string(plainBody))
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	block, body, ok := splitFrontMatter([]byte("---\ntitle: x\n---\nbody\n"))
+	assert.True(t, ok)
+	assert.Equal(t, "title: x\n", string(block))
+	assert.Equal(t, "body\n", string(body))
+
+	_, body, ok = splitFrontMatter([]byte("no front matter\n"))
+	assert.False(t, ok)
+	assert.Equal(t, "no front matter\n", string(body))
+}
@@ -0,0 +1,107 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// JSONFeedSource reads a JSON array of entries - from a local file or a
+// remote "http(s)://" URL - and yields one Page per entry. It's meant for
+// content that lives in some external system rather than as files in the
+// repo, so unlike FileSystemSource and FrontMatterSource, the Pages it
+// produces don't implement Pather: there's no single file on disk to
+// attribute a change to, only the feed as a whole.
+type JSONFeedSource struct {
+	Location string
+}
+
+// NewJSONFeedSource returns a JSONFeedSource reading from location, which is
+// either a local file path or an http(s) URL.
+func NewJSONFeedSource(location string) *JSONFeedSource {
+	return &JSONFeedSource{Location: location}
+}
+
+// jsonFeedEntry is the wire format of a single entry in the feed.
+type jsonFeedEntry struct {
+	Slug    string                 `json:"slug"`
+	Title   string                 `json:"title"`
+	Date    string                 `json:"date"`
+	Draft   bool                   `json:"draft"`
+	Aliases []string               `json:"aliases"`
+	Body    string                 `json:"body"`
+	Meta    map[string]interface{} `json:"meta"`
+}
+
+func (s *JSONFeedSource) Pages() ([]Page, error) {
+	r, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var entries []jsonFeedEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Location, err)
+	}
+
+	pages := make([]Page, len(entries))
+	for i, entry := range entries {
+		page, err := newJSONFeedPage(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.Location, err)
+		}
+		pages[i] = page
+	}
+
+	return pages, nil
+}
+
+func (s *JSONFeedSource) open() (io.ReadCloser, error) {
+	if strings.HasPrefix(s.Location, "http://") || strings.HasPrefix(s.Location, "https://") {
+		resp, err := http.Get(s.Location)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s: unexpected status %v", s.Location, resp.Status)
+		}
+		return resp.Body, nil
+	}
+
+	return os.Open(s.Location)
+}
+
+// jsonFeedPage is the Page backing a single entry read by JSONFeedSource.
+type jsonFeedPage struct {
+	entry jsonFeedEntry
+	date  time.Time
+}
+
+func newJSONFeedPage(entry jsonFeedEntry) (*jsonFeedPage, error) {
+	page := &jsonFeedPage{entry: entry}
+
+	if entry.Date != "" {
+		date, err := parseFrontMatterDate(entry.Date)
+		if err != nil {
+			return nil, err
+		}
+		page.date = date
+	}
+
+	return page, nil
+}
+
+func (p *jsonFeedPage) Slug() string                { return p.entry.Slug }
+func (p *jsonFeedPage) Title() string               { return p.entry.Title }
+func (p *jsonFeedPage) PublishedAt() time.Time      { return p.date }
+func (p *jsonFeedPage) Draft() bool                 { return p.entry.Draft }
+func (p *jsonFeedPage) Aliases() []string           { return p.entry.Aliases }
+func (p *jsonFeedPage) Meta() map[string]interface{} { return p.entry.Meta }
+func (p *jsonFeedPage) OutputPath() string          { return outputPath(p.entry.Slug) }
+func (p *jsonFeedPage) Body() ([]byte, error)       { return []byte(p.entry.Body), nil }
@@ -0,0 +1,60 @@
+package content
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestJSONFeedSourcePages(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := path.Join(dir, "articles.json")
+
+	err := os.WriteFile(feedPath, []byte(`[
+		{
+			"slug": "from-feed",
+			"title": "From The Feed",
+			"date": "2023-05-01T00:00:00Z",
+			"body": "<p>hi</p>",
+			"meta": {"source": "external"}
+		},
+		{
+			"slug": "draft-entry",
+			"title": "Not Ready Yet",
+			"draft": true,
+			"aliases": ["old-slug"]
+		}
+	]`), 0644)
+	assert.NoError(t, err)
+
+	pages, err := NewJSONFeedSource(feedPath).Pages()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pages))
+
+	first := pages[0]
+	assert.Equal(t, "from-feed", first.Slug())
+	assert.Equal(t, "From The Feed", first.Title())
+	assert.Equal(t, time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), first.PublishedAt())
+	assert.False(t, first.Draft())
+	assert.Equal(t, "external", first.Meta()["source"])
+
+	body, err := first.Body()
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>hi</p>", string(body))
+
+	second := pages[1]
+	assert.True(t, second.Draft())
+	assert.Equal(t, []string{"old-slug"}, second.Aliases())
+
+	// Pages from a feed have no backing file of their own.
+	_, ok := interface{}(first).(Pather)
+	assert.False(t, ok)
+}
+
+func TestJSONFeedSourceMissingFile(t *testing.T) {
+	_, err := NewJSONFeedSource("/nonexistent/articles.json").Pages()
+	assert.Error(t, err)
+}
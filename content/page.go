@@ -0,0 +1,68 @@
+// Package content abstracts an article away from wherever it actually comes
+// from. Historically that was always a Markdown file directly under
+// content/articles, but Source makes it possible to pull pages from other
+// places (a JSON feed, front matter metadata, eventually something else
+// entirely) without the rest of the build - compileArticle, the dependency
+// graph, the templates - needing to know or care which one produced a given
+// Page.
+package content
+
+import "time"
+
+// Page is a single piece of content to be rendered into the site: an
+// article, or an entry pulled from some other source that produces the same
+// shape of data.
+type Page interface {
+	// Slug is the page's identifier, with no source-specific extension or
+	// directory prefix - e.g. "hello-world" for a page sourced from
+	// content/articles/hello-world.md.
+	Slug() string
+
+	// Title is the page's display title.
+	Title() string
+
+	// PublishedAt is when the page was published. It's the zero time.Time
+	// if the source doesn't know.
+	PublishedAt() time.Time
+
+	// Draft is whether the page should be excluded from a normal build.
+	Draft() bool
+
+	// Aliases are additional URLs that should redirect to OutputPath.
+	Aliases() []string
+
+	// Body returns the page's raw, unrendered content.
+	Body() ([]byte, error)
+
+	// Meta is a bag of source-specific metadata, made available to
+	// templates as .Page.Meta.
+	Meta() map[string]interface{}
+
+	// OutputPath is the path, relative to TargetDir, that the page renders
+	// to.
+	OutputPath() string
+}
+
+// Source produces the set of Pages it currently knows about.
+type Source interface {
+	Pages() ([]Page, error)
+}
+
+// Pather is implemented by a Page that's backed by a single file on disk. It
+// lets callers that need a concrete path to watch or hash - like the
+// dependency graph - get one, without every Source being required to have
+// one (an entry from a JSON feed has no per-page file of its own).
+type Pather interface {
+	Path() string
+}
+
+// outputPath is the OutputPath shared by every file-backed source: ordinary
+// slugs become the slug itself, but "index" gets an explicit .html extension
+// so it's served from a directory-level request instead of a directory
+// listing.
+func outputPath(slug string) string {
+	if slug == "index" {
+		return slug + ".html"
+	}
+	return slug
+}
@@ -0,0 +1,249 @@
+// Package deps tracks, for every file the build writes to TargetDir, the
+// full set of inputs that went into producing it: the source markdown, every
+// layout/partial loaded to render it, every image or asset it references,
+// and so on. That's enough information to invert the graph on a file change
+// and figure out the minimal set of outputs that actually need to be
+// rebuilt, which is what makes `serve` mode's incremental rebuilds possible.
+//
+// Collection is threaded through a context.Context rather than passed
+// explicitly, since the functions that need to report a dependency
+// (compileArticle, renderView, renderAliasTarget, ...) are many
+// layers removed from the code that knows which output is currently being
+// built.
+package deps
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Kind categorizes a single dependency edge.
+type Kind string
+
+const (
+	// KindSource is the article or page's own source file.
+	KindSource Kind = "source"
+
+	// KindLayout is an .ace layout or partial loaded via ace.Load.
+	KindLayout Kind = "layout"
+
+	// KindImage is an image, font, or other static asset linked or compiled
+	// into TargetDir/assets.
+	KindImage Kind = "image"
+)
+
+// Dep is a single input consumed while producing some output.
+type Dep struct {
+	Kind Kind
+	Path string
+}
+
+// DepSet accumulates the dependencies discovered while building a single
+// output. It's safe for concurrent use since a single output may pull in
+// dependencies from multiple goroutines (e.g. parallel partial rendering).
+type DepSet struct {
+	mu   sync.Mutex
+	deps map[Dep]struct{}
+}
+
+// NewDepSet returns an empty DepSet.
+func NewDepSet() *DepSet {
+	return &DepSet{deps: make(map[Dep]struct{})}
+}
+
+// Add records a single dependency. It's idempotent: adding the same (kind,
+// path) pair twice has no additional effect.
+func (s *DepSet) Add(kind Kind, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deps[Dep{Kind: kind, Path: path}] = struct{}{}
+}
+
+// List returns every dependency added so far, sorted for determinism.
+func (s *DepSet) List() []Dep {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deps := make([]Dep, 0, len(s.deps))
+	for dep := range s.deps {
+		deps = append(deps, dep)
+	}
+
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Kind != deps[j].Kind {
+			return deps[i].Kind < deps[j].Kind
+		}
+		return deps[i].Path < deps[j].Path
+	})
+
+	return deps
+}
+
+//
+// Context plumbing
+//
+
+type collectorKey struct{}
+
+// WithCollector returns a context that Collect will report dependencies
+// into. Code that doesn't care about dependency tracking can simply not
+// call this, in which case Collect becomes a no-op.
+func WithCollector(ctx context.Context, set *DepSet) context.Context {
+	return context.WithValue(ctx, collectorKey{}, set)
+}
+
+// Collect reports a single dependency against whatever DepSet was attached
+// to ctx via WithCollector, if any.
+func Collect(ctx context.Context, kind Kind, path string) {
+	if set, ok := ctx.Value(collectorKey{}).(*DepSet); ok {
+		set.Add(kind, path)
+	}
+}
+
+//
+// Graph
+//
+
+// OutputInfo is everything the graph remembers about a single output file.
+type OutputInfo struct {
+	// Deps are the inputs that were consumed to produce this output.
+	Deps []Dep
+
+	// Source, if non-empty, identifies what the task that produced this
+	// output was built from - e.g. the slug of the page passed to
+	// compileArticle, or the image file or asset directory an asset task
+	// linked or compiled. It lets an incremental rebuild map "this output
+	// is affected" back to "here's the task that needs to rerun."
+	Source string
+}
+
+// Graph is the full, persisted record of every output's dependencies.
+type Graph struct {
+	mu sync.Mutex
+
+	// Outputs maps an output's path (relative to TargetDir) to what went
+	// into producing it.
+	Outputs map[string]OutputInfo
+}
+
+// NewGraph returns an empty Graph.
+func NewGraph() *Graph {
+	return &Graph{Outputs: make(map[string]OutputInfo)}
+}
+
+// Record stores (or replaces) the dependency set for the given output.
+func (g *Graph) Record(output string, source string, set *DepSet) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Outputs[output] = OutputInfo{Deps: set.List(), Source: source}
+}
+
+// AffectedOutputs inverts the graph: given a set of changed input paths, it
+// returns every output whose recorded dependencies intersect that set.
+func (g *Graph) AffectedOutputs(changed map[string]bool) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var affected []string
+	for output, info := range g.Outputs {
+		for _, dep := range info.Deps {
+			if changed[dep.Path] {
+				affected = append(affected, output)
+				break
+			}
+		}
+	}
+
+	sort.Strings(affected)
+	return affected
+}
+
+// Sources returns the Source field (see OutputInfo) of every output in
+// outputs that has one, deduplicated. Outputs with no recorded source
+// (nothing currently leaves one blank, but future output kinds might) are
+// silently skipped; callers should treat a request for an output with no
+// known source as "fall back to a full rebuild."
+func (g *Graph) Sources(outputs []string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var sources []string
+	for _, output := range outputs {
+		source := g.Outputs[output].Source
+		if source == "" || seen[source] {
+			continue
+		}
+		seen[source] = true
+		sources = append(sources, source)
+	}
+
+	sort.Strings(sources)
+	return sources
+}
+
+// LoadGraph reads a Graph previously written by Save. A missing file is not
+// an error: it just means there's no prior graph to diff against, so
+// everything should be rebuilt once to populate one.
+func LoadGraph(path string) (*Graph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewGraph(), nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	graph := NewGraph()
+	if err := gob.NewDecoder(f).Decode(&graph.Outputs); err != nil {
+		return nil, err
+	}
+
+	return graph, nil
+}
+
+// PrintGraph writes one line per dependency edge, in the form
+// "<output> <- <kind> <path>", for every output in the graph. It's the data
+// behind the top-level `graph` subcommand.
+func (g *Graph) PrintGraph(w io.Writer) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	outputs := make([]string, 0, len(g.Outputs))
+	for output := range g.Outputs {
+		outputs = append(outputs, output)
+	}
+	sort.Strings(outputs)
+
+	for _, output := range outputs {
+		for _, dep := range g.Outputs[output].Deps {
+			if _, err := fmt.Fprintf(w, "%s <- %s %s\n", output, dep.Kind, dep.Path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Save persists the graph to path (typically
+// TargetDir/.singularity-deps.gob) so that a later invocation can load it
+// with LoadGraph.
+func (g *Graph) Save(path string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gob.NewEncoder(f).Encode(g.Outputs)
+}
@@ -0,0 +1,83 @@
+package deps
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestDepSetList(t *testing.T) {
+	set := NewDepSet()
+	set.Add(KindSource, "articles/hello.md")
+	set.Add(KindLayout, "layouts/main.ace")
+	set.Add(KindLayout, "layouts/main.ace") // duplicate, should collapse
+
+	list := set.List()
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, Dep{Kind: KindLayout, Path: "layouts/main.ace"}, list[0])
+	assert.Equal(t, Dep{Kind: KindSource, Path: "articles/hello.md"}, list[1])
+}
+
+func TestCollect(t *testing.T) {
+	set := NewDepSet()
+	ctx := WithCollector(context.Background(), set)
+
+	Collect(ctx, KindImage, "content/images/photo.jpg")
+	assert.Equal(t, 1, len(set.List()))
+
+	// Collecting against a bare context (no collector attached) is a no-op
+	// rather than a panic.
+	Collect(context.Background(), KindImage, "content/images/other.jpg")
+}
+
+func TestGraphAffectedOutputs(t *testing.T) {
+	graph := NewGraph()
+
+	setA := NewDepSet()
+	setA.Add(KindSource, "articles/a.md")
+	setA.Add(KindLayout, "layouts/main.ace")
+	graph.Record("a.html", "a.md", setA)
+
+	setB := NewDepSet()
+	setB.Add(KindSource, "articles/b.md")
+	setB.Add(KindLayout, "layouts/main.ace")
+	graph.Record("b.html", "b.md", setB)
+
+	// Only a.md changing affects only a.html.
+	affected := graph.AffectedOutputs(map[string]bool{"articles/a.md": true})
+	assert.Equal(t, []string{"a.html"}, affected)
+
+	// The shared layout changing affects both.
+	affected = graph.AffectedOutputs(map[string]bool{"layouts/main.ace": true})
+	assert.Equal(t, []string{"a.html", "b.html"}, affected)
+
+	sources := graph.Sources(affected)
+	assert.Equal(t, []string{"a.md", "b.md"}, sources)
+}
+
+func TestGraphSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	graphPath := path.Join(dir, ".singularity-deps.gob")
+
+	// No graph on disk yet: LoadGraph returns an empty one rather than an
+	// error.
+	graph, err := LoadGraph(graphPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(graph.Outputs))
+
+	set := NewDepSet()
+	set.Add(KindSource, "articles/a.md")
+	graph.Record("a.html", "a.md", set)
+
+	assert.NoError(t, graph.Save(graphPath))
+
+	reloaded, err := LoadGraph(graphPath)
+	assert.NoError(t, err)
+	assert.Equal(t, graph.Outputs, reloaded.Outputs)
+
+	_, err = os.Stat(graphPath)
+	assert.NoError(t, err)
+}
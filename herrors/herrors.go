@@ -0,0 +1,143 @@
+// Package herrors ("human errors") knows how to take the raw error values
+// returned by ace.Load, template.Execute, and markdown.Render and turn them
+// into something a person can act on: a file, a line, and a few lines of
+// surrounding source.
+//
+// None of those libraries expose structured position information, but they
+// do all embed a "file:line" or "file:line:col" fragment somewhere in their
+// error message, so we fall back to parsing it out with a regexp.
+package herrors
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// ContextLines is the number of lines of source shown before and after the
+// offending line in a SourceError's excerpt.
+const ContextLines = 3
+
+// locationPattern matches a "file:line" or "file:line:col" fragment as
+// embedded in errors from ace, html/template, and blackfriday/goldmark.
+var locationPattern = regexp.MustCompile(`([^\s:]+\.(?:ace|md|markdown|tmpl)):(\d+)(?::(\d+))?`)
+
+// SourceError is an error that's been successfully traced back to a specific
+// line in a specific source file.
+type SourceError struct {
+	// Col is the column on Line where the error occurred. It's 0 if the
+	// underlying error didn't include column information.
+	Col int
+
+	// Excerpt is a few lines of source around Line for display purposes. It's
+	// nil if the source file couldn't be reopened.
+	Excerpt []SourceLine
+
+	// File is the path to the file that produced the error, as reported by
+	// the underlying library.
+	File string
+
+	// Line is the 1-indexed line on which the error occurred.
+	Line int
+
+	// Message is the original error's message, in full.
+	Message string
+}
+
+// SourceLine is a single line of source as part of a SourceError's excerpt.
+type SourceLine struct {
+	// Current is true if this is the exact line the error occurred on.
+	Current bool
+
+	// Number is the 1-indexed line number within the source file.
+	Number int
+
+	// Text is the raw (unescaped) text of the line.
+	Text string
+}
+
+// Parse tries to recover file/line/column information from err's message. It
+// returns false if no location could be found, in which case the caller
+// should fall back to displaying err's message on its own.
+func Parse(err error) (*SourceError, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	matches := locationPattern.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return nil, false
+	}
+
+	file := matches[1]
+
+	line, convErr := strconv.Atoi(matches[2])
+	if convErr != nil {
+		return nil, false
+	}
+
+	var col int
+	if matches[3] != "" {
+		// Ignore the error: if this doesn't parse we just fall back to 0,
+		// which is still a strict improvement over having no column at all.
+		col, _ = strconv.Atoi(matches[3])
+	}
+
+	sourceErr := &SourceError{
+		Col:     col,
+		File:    file,
+		Line:    line,
+		Message: err.Error(),
+	}
+
+	// It's fine if we can't reopen the file (it may have been a relative
+	// path resolved against a working directory we no longer have, or the
+	// file may have been deleted since); we still have useful location
+	// information even without an excerpt.
+	excerpt, excerptErr := Excerpt(file, line, ContextLines)
+	if excerptErr == nil {
+		sourceErr.Excerpt = excerpt
+	}
+
+	return sourceErr, true
+}
+
+// Excerpt reads the source lines surrounding line (inclusive) out of file,
+// including up to context lines of padding on either side.
+func Excerpt(file string, line, context int) ([]SourceLine, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	start := line - context
+	if start < 1 {
+		start = 1
+	}
+	end := line + context
+
+	var lines []SourceLine
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		if n < start {
+			continue
+		}
+		if n > end {
+			break
+		}
+
+		lines = append(lines, SourceLine{
+			Current: n == line,
+			Number:  n,
+			Text:    scanner.Text(),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,79 @@
+package herrors
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	dir, err := os.MkdirTemp("", "herrors")
+	assert.NoError(t, err)
+
+	file := path.Join(dir, "article.md")
+	err = os.WriteFile(file, []byte("one\ntwo\nthree\nfour\nfive\n"), 0644)
+	assert.NoError(t, err)
+
+	//
+	// Location with a column
+	//
+
+	sourceErr, ok := Parse(fmt.Errorf("%s:3:5: unexpected token", file))
+	assert.True(t, ok)
+	assert.Equal(t, file, sourceErr.File)
+	assert.Equal(t, 3, sourceErr.Line)
+	assert.Equal(t, 5, sourceErr.Col)
+	assert.Equal(t, "three", sourceErr.Excerpt[2].Text)
+	assert.True(t, sourceErr.Excerpt[2].Current)
+
+	//
+	// Location without a column
+	//
+
+	sourceErr, ok = Parse(fmt.Errorf("%s:2: template: no such template", file))
+	assert.True(t, ok)
+	assert.Equal(t, 2, sourceErr.Line)
+	assert.Equal(t, 0, sourceErr.Col)
+
+	//
+	// No location in the message at all
+	//
+
+	_, ok = Parse(fmt.Errorf("something went horribly wrong"))
+	assert.False(t, ok)
+
+	//
+	// nil error
+	//
+
+	_, ok = Parse(nil)
+	assert.False(t, ok)
+}
+
+func TestExcerpt(t *testing.T) {
+	dir, err := os.MkdirTemp("", "herrors")
+	assert.NoError(t, err)
+
+	file := path.Join(dir, "article.md")
+	err = os.WriteFile(file, []byte("one\ntwo\nthree\nfour\nfive\n"), 0644)
+	assert.NoError(t, err)
+
+	lines, err := Excerpt(file, 3, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "two", lines[0].Text)
+	assert.Equal(t, "three", lines[1].Text)
+	assert.True(t, lines[1].Current)
+	assert.Equal(t, "four", lines[2].Text)
+
+	// Context clamps at the start of the file instead of going negative.
+	lines, err = Excerpt(file, 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, lines[0].Number)
+
+	_, err = Excerpt(path.Join(dir, "nonexistent.md"), 1, 1)
+	assert.Error(t, err)
+}
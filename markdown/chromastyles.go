@@ -0,0 +1,49 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// ChromaCSSOptions controls how WriteChromaCSS renders line-number
+// selectors, mirroring Chroma's own CLI flags of the same name.
+type ChromaCSSOptions struct {
+	// LineNumbersInlineStyle numbers every line with an inline <span>,
+	// rather than a separate table column.
+	LineNumbersInlineStyle bool
+
+	// LineNumbersTableStyle numbers lines in their own table column, so
+	// they're excluded from text selection and copy/paste.
+	LineNumbersTableStyle bool
+}
+
+// WriteChromaCSS writes the standalone stylesheet for styleName - every CSS
+// class Chroma's HTML formatter can emit, for every token type the style
+// defines - to w. It's the backing implementation of the `gen chromastyles`
+// subcommand: since fenced code blocks are rendered with
+// chromahtml.WithClasses (see GoldmarkRenderer), the actual HTML only
+// references class names, and this stylesheet is what gives them color.
+func WriteChromaCSS(w io.Writer, styleName string, opts ChromaCSSOptions) error {
+	// styles.Get never returns nil: an unregistered name silently resolves
+	// to styles.Fallback instead, so an invalid name has to be caught
+	// against the registry directly.
+	if _, ok := styles.Registry[styleName]; !ok {
+		return fmt.Errorf("unknown chroma style: %q", styleName)
+	}
+	style := styles.Get(styleName)
+
+	var formatterOpts []chromahtml.Option
+	formatterOpts = append(formatterOpts, chromahtml.WithClasses(true))
+
+	if opts.LineNumbersTableStyle {
+		formatterOpts = append(formatterOpts, chromahtml.WithLineNumbers(true), chromahtml.LineNumbersInTable(true))
+	} else if opts.LineNumbersInlineStyle {
+		formatterOpts = append(formatterOpts, chromahtml.WithLineNumbers(true))
+	}
+
+	formatter := chromahtml.New(formatterOpts...)
+	return formatter.WriteCSS(w, style)
+}
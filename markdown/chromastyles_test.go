@@ -0,0 +1,21 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestWriteChromaCSS(t *testing.T) {
+	var buf strings.Builder
+	err := WriteChromaCSS(&buf, "monokai", ChromaCSSOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), ".chroma")
+}
+
+func TestWriteChromaCSSUnknownStyle(t *testing.T) {
+	var buf strings.Builder
+	err := WriteChromaCSS(&buf, "not-a-real-style", ChromaCSSOptions{})
+	assert.EqualError(t, err, `unknown chroma style: "not-a-real-style"`)
+}
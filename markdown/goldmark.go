@@ -0,0 +1,128 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/shurcooL/sanitized_anchor_name"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// DefaultChromaStyle is the Chroma style used to highlight fenced code
+// blocks, overridable via the CHROMA_STYLE environment variable.
+func DefaultChromaStyle() string {
+	if style := os.Getenv("CHROMA_STYLE"); style != "" {
+		return style
+	}
+	return "monokai"
+}
+
+// GoldmarkRenderer is the default Renderer: yuin/goldmark configured with
+// GitHub-flavored extensions, Chroma syntax highlighting, and
+// sanitized_anchor_name-compatible heading anchors.
+type GoldmarkRenderer struct {
+	md goldmark.Markdown
+}
+
+// NewGoldmarkRenderer returns a GoldmarkRenderer highlighting code with
+// DefaultChromaStyle().
+func NewGoldmarkRenderer() *GoldmarkRenderer {
+	return NewGoldmarkRendererWithStyle(DefaultChromaStyle())
+}
+
+// NewGoldmarkRendererWithStyle returns a GoldmarkRenderer highlighting code
+// with the named Chroma style.
+func NewGoldmarkRendererWithStyle(chromaStyle string) *GoldmarkRenderer {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			// GFM covers tables, strikethrough, autolinking, and GitHub-style
+			// task lists in one extension.
+			extension.GFM,
+			extension.DefinitionList,
+			extension.Footnote,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle(chromaStyle),
+				highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+			),
+		),
+		goldmark.WithParserOptions(
+			parser.WithASTTransformers(util.Prioritized(headingAnchorTransformer{}, 100)),
+		),
+		goldmark.WithRendererOptions(
+			// Articles are our own content, so raw/inline HTML is trusted the
+			// same way it always has been.
+			html.WithUnsafe(),
+		),
+	)
+
+	return &GoldmarkRenderer{md: md}
+}
+
+func (r *GoldmarkRenderer) Render(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := r.md.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// headingAnchorTransformer assigns every heading an "id" attribute derived
+// from its text via sanitized_anchor_name, matching the IDs singularity's
+// previous, blackfriday-based renderer always produced. toc.Render parses
+// those ids back out of the rendered HTML, so keeping the scheme unchanged
+// means it didn't need to change alongside this package.
+type headingAnchorTransformer struct{}
+
+func (headingAnchorTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	seen := make(map[string]int)
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		heading, ok := n.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+
+		name := sanitized_anchor_name.Create(string(headingText(heading, reader.Source())))
+
+		if count := seen[name]; count > 0 {
+			// blackfriday's own disambiguation scheme for a repeated
+			// heading: append "-N", counting up from 1.
+			heading.SetAttributeString("id", []byte(fmt.Sprintf("%s-%d", name, count)))
+		} else {
+			heading.SetAttributeString("id", []byte(name))
+		}
+		seen[name]++
+
+		return ast.WalkContinue, nil
+	})
+}
+
+// headingText concatenates the raw text of every text leaf under n, which is
+// what sanitized_anchor_name needs to compute a heading's slug.
+func headingText(n ast.Node, source []byte) []byte {
+	var buf bytes.Buffer
+
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if textNode, ok := c.(*ast.Text); ok {
+			buf.Write(textNode.Segment.Value(source))
+			continue
+		}
+		buf.Write(headingText(c, source))
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,39 @@
+// Package markdown renders article content from Markdown to HTML.
+//
+// Rendering goes through a pluggable Renderer so that the compiled dialect
+// can change without every caller needing to change with it. The default
+// Renderer (NewGoldmarkRenderer) wraps yuin/goldmark with Chroma-highlighted
+// fenced code blocks, GitHub-style task lists, definition lists, footnotes,
+// and heading anchors generated the same way shurcooL/sanitized_anchor_name
+// always has, so toc.Render's output doesn't need to change alongside it.
+package markdown
+
+// Options customizes a single Render call. A nil Options is equivalent to
+// the zero value.
+type Options struct {
+	// Renderer overrides Default for this call.
+	Renderer Renderer
+}
+
+// Renderer turns Markdown source into HTML.
+type Renderer interface {
+	Render(source string) (string, error)
+}
+
+// Default is the Renderer used by Render when opts is nil or leaves
+// Renderer unset.
+var Default Renderer = NewGoldmarkRenderer()
+
+// Render renders source to HTML using opts.Renderer, or Default if opts is
+// nil or leaves it unset. A rendering failure is returned as-is so that
+// callers like
+// compileArticle can route it through herrors the same way an ace or
+// template error would be, rather than it being silently papered over.
+func Render(source string, opts *Options) (string, error) {
+	renderer := Default
+	if opts != nil && opts.Renderer != nil {
+		renderer = opts.Renderer
+	}
+
+	return renderer.Render(source)
+}
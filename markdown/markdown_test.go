@@ -0,0 +1,60 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestRenderHeadingAnchors(t *testing.T) {
+	rendered, err := Render("# Hello World\n\nSome text.\n\n## Hello World\n", nil)
+	assert.NoError(t, err)
+
+	assert.Contains(t, rendered, `id="hello-world"`)
+	assert.Contains(t, rendered, `id="hello-world-1"`)
+}
+
+func TestRenderCodeBlockHighlighting(t *testing.T) {
+	rendered, err := Render("```go\nfunc main() {}\n```\n", nil)
+	assert.NoError(t, err)
+
+	// Chroma renders highlighted tokens as <span>s with a "chroma" wrapper;
+	// the exact class names depend on the style, but the wrapper is stable.
+	assert.Contains(t, rendered, `class="chroma`)
+}
+
+func TestRenderTaskList(t *testing.T) {
+	rendered, err := Render("- [x] done\n- [ ] not done\n", nil)
+	assert.NoError(t, err)
+
+	assert.Contains(t, rendered, `type="checkbox"`)
+	assert.Contains(t, rendered, `checked`)
+}
+
+func TestRenderCustomRenderer(t *testing.T) {
+	rendered, err := Render("anything", &Options{
+		Renderer: rendererFunc(func(source string) (string, error) {
+			return strings.ToUpper(source), nil
+		}),
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "ANYTHING", rendered)
+}
+
+func TestRenderError(t *testing.T) {
+	_, err := Render("anything", &Options{
+		Renderer: rendererFunc(func(source string) (string, error) {
+			return "", fmt.Errorf("boom")
+		}),
+	})
+	assert.EqualError(t, err, "boom")
+}
+
+type rendererFunc func(source string) (string, error)
+
+func (f rendererFunc) Render(source string) (string, error) {
+	return f(source)
+}
@@ -0,0 +1,96 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// CacheDir returns the directory that fetched modules are cached under:
+// ~/.cache/singularity/modules.
+func CacheDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(userCacheDir, "singularity", "modules"), nil
+}
+
+// Dir returns the on-disk directory that holds req's files, fetching it
+// first if necessary. projectDir is the root of the site doing the
+// importing, used to resolve local "themes/" requirements.
+func Dir(req Requirement, projectDir string) (string, error) {
+	if req.IsLocal() {
+		return filepath.Join(projectDir, req.Path), nil
+	}
+
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return Fetch(req, cacheDir)
+}
+
+// Fetch ensures a remote module is present in cacheDir, cloning it with Git
+// if it isn't already, and returns the directory it lives in.
+func Fetch(req Requirement, cacheDir string) (string, error) {
+	if req.IsLocal() {
+		return "", fmt.Errorf("modules: Fetch called on local module %q", req.Path)
+	}
+
+	version := req.Version
+	if version == "" {
+		version = "HEAD"
+	}
+
+	dir := filepath.Join(cacheDir, moduleCacheKey(req.Path, version))
+
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", err
+	}
+
+	// Clone into a temporary sibling directory first and rename it into
+	// place once we know it succeeded, so a failed or interrupted fetch
+	// can't leave a half-cloned module in the cache.
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".fetch-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	args := []string{"clone", "--depth", "1"}
+	if req.Version != "" {
+		args = append(args, "--branch", req.Version)
+	}
+	args = append(args, "https://"+req.Path, tmp)
+
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("modules: fetching %s: %w", req.Path, err)
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// moduleCacheKey turns a module path and version into a filesystem-safe
+// directory name, mirroring the "@" separator Go's own module cache uses.
+func moduleCacheKey(path, version string) string {
+	safePath := strings.ReplaceAll(path, "/", "_")
+	return safePath + "@" + version
+}
@@ -0,0 +1,172 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolvedModule is a Requirement together with the on-disk directory its
+// files were fetched (or found) at.
+type resolvedModule struct {
+	req Requirement
+	dir string
+}
+
+// FS is a layered virtual filesystem over a project's seven standard
+// component roots: the project's own files, followed by every module it
+// imports. Lookups prefer the project, then walk imported modules in
+// reverse import order so that a later import overrides an earlier one.
+type FS struct {
+	ProjectDir string
+	modules    []resolvedModule
+}
+
+// Load builds an FS for the project at projectDir by reading its
+// singularity.mod (if any) and resolving (fetching, where necessary) every
+// module it requires. It returns (nil, nil) if the project has no manifest,
+// since callers should treat that as "don't use the module system."
+func Load(projectDir string) (*FS, error) {
+	manifest, err := LoadManifest(filepath.Join(projectDir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, nil
+	}
+
+	fs := &FS{ProjectDir: projectDir}
+
+	for _, req := range manifest.Requires {
+		dir, err := Dir(req, projectDir)
+		if err != nil {
+			return nil, err
+		}
+		fs.modules = append(fs.modules, resolvedModule{req: req, dir: dir})
+	}
+
+	return fs, nil
+}
+
+// Entry is a single resolved file within a standard root: its name and the
+// real, on-disk path that should be used to read it.
+type Entry struct {
+	Name string
+	Path string
+}
+
+// Resolve returns the real, on-disk path to use for logicalPath (e.g.
+// "layouts/article.ace"), checking the project first and then every
+// imported module, in reverse import order.
+func (fs *FS) Resolve(logicalPath string) (string, error) {
+	root, rest, err := splitRoot(logicalPath)
+	if err != nil {
+		return "", err
+	}
+
+	projectPath := filepath.Join(fs.ProjectDir, root, rest)
+	if _, err := os.Stat(projectPath); err == nil {
+		return projectPath, nil
+	}
+
+	for i := len(fs.modules) - 1; i >= 0; i-- {
+		m := fs.modules[i]
+		for _, mount := range m.req.Mounts {
+			if mount.Target != root {
+				continue
+			}
+
+			candidate := filepath.Join(m.dir, mount.Source, rest)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("modules: %q not found in project or any imported module", logicalPath)
+}
+
+// ReadDir merges the project's own entries under root with every imported
+// module mounted to it, project winning ties and later imports overriding
+// earlier ones.
+func (fs *FS) ReadDir(root string) ([]Entry, error) {
+	if !isStandardRoot(root) {
+		return nil, fmt.Errorf("modules: %q is not one of the standard component roots %v", root, StandardRoots)
+	}
+
+	seen := make(map[string]string)
+
+	addDir(seen, filepath.Join(fs.ProjectDir, root))
+
+	for i := len(fs.modules) - 1; i >= 0; i-- {
+		m := fs.modules[i]
+		for _, mount := range m.req.Mounts {
+			if mount.Target == root {
+				addDir(seen, filepath.Join(m.dir, mount.Source))
+			}
+		}
+	}
+
+	entries := make([]Entry, 0, len(seen))
+	for name, path := range seen {
+		entries = append(entries, Entry{Name: name, Path: path})
+	}
+
+	return entries, nil
+}
+
+// Materialize resolves every file under root (see ReadDir) and symlinks it
+// into a fresh temporary directory, returning that directory's path. It's
+// useful for callers like asset compilation that expect a single flat
+// directory on disk rather than dealing with layering themselves.
+func (fs *FS) Materialize(root string) (string, error) {
+	entries, err := fs.ReadDir(root)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "singularity-"+strings.ReplaceAll(root, "/", "-")+"-")
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		abs, err := filepath.Abs(entry.Path)
+		if err != nil {
+			return "", err
+		}
+
+		if err := os.Symlink(abs, filepath.Join(dir, entry.Name)); err != nil {
+			return "", err
+		}
+	}
+
+	return dir, nil
+}
+
+func addDir(seen map[string]string, dir string) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, f := range files {
+		if _, ok := seen[f.Name()]; !ok {
+			seen[f.Name()] = filepath.Join(dir, f.Name())
+		}
+	}
+}
+
+func splitRoot(logicalPath string) (root, rest string, err error) {
+	for _, r := range StandardRoots {
+		if logicalPath == r {
+			return r, "", nil
+		}
+		if strings.HasPrefix(logicalPath, r+"/") {
+			return r, strings.TrimPrefix(logicalPath, r+"/"), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("modules: %q is not under one of the standard component roots %v", logicalPath, StandardRoots)
+}
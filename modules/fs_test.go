@@ -0,0 +1,67 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+// writeFile creates dir and a file at dir/name with the given content.
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func TestFSResolveAndReadDir(t *testing.T) {
+	project := t.TempDir()
+	themeA := t.TempDir()
+	themeB := t.TempDir()
+
+	// Only the older theme has "footer.ace".
+	writeFile(t, filepath.Join(themeA, "layouts"), "footer.ace", "theme-a footer")
+	writeFile(t, filepath.Join(themeA, "layouts"), "main.ace", "theme-a main")
+
+	// The newer theme overrides "main.ace".
+	writeFile(t, filepath.Join(themeB, "layouts"), "main.ace", "theme-b main")
+
+	// The project overrides "main.ace" again.
+	writeFile(t, filepath.Join(project, "layouts"), "main.ace", "project main")
+
+	fs := &FS{
+		ProjectDir: project,
+		modules: []resolvedModule{
+			{req: Requirement{Path: "themes/a", Mounts: []Mount{{Source: "layouts", Target: "layouts"}}}, dir: themeA},
+			{req: Requirement{Path: "themes/b", Mounts: []Mount{{Source: "layouts", Target: "layouts"}}}, dir: themeB},
+		},
+	}
+
+	// The project always wins.
+	resolved, err := fs.Resolve("layouts/main.ace")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(project, "layouts", "main.ace"), resolved)
+
+	// Falls through to the module that actually has the file.
+	resolved, err = fs.Resolve("layouts/footer.ace")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(themeA, "layouts", "footer.ace"), resolved)
+
+	_, err = fs.Resolve("layouts/missing.ace")
+	assert.Error(t, err)
+
+	entries, err := fs.ReadDir("layouts")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(entries))
+}
+
+func TestFSResolveRejectsNonStandardRoot(t *testing.T) {
+	fs := &FS{ProjectDir: t.TempDir()}
+
+	_, err := fs.Resolve("not-a-root/foo")
+	assert.Error(t, err)
+
+	_, err = fs.ReadDir("not-a-root")
+	assert.Error(t, err)
+}
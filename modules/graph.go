@@ -0,0 +1,36 @@
+package modules
+
+import (
+	"fmt"
+	"io"
+)
+
+// PrintGraph writes one line per resolved import edge, in the form
+// "<module> <version>: <mount source> -> <mount target>", for every mount
+// every required module contributes, or just "<module> <version>" for a
+// module with no mounts. The version reads "(local)" for a local module and
+// is empty for a remote one with no pinned version. It's the data behind
+// the `mod graph` subcommand.
+func PrintGraph(w io.Writer, manifest *Manifest) error {
+	for _, req := range manifest.Requires {
+		version := req.Version
+		if req.IsLocal() {
+			version = "(local)"
+		}
+
+		if len(req.Mounts) == 0 {
+			if _, err := fmt.Fprintf(w, "%s %s\n", req.Path, version); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, mount := range req.Mounts {
+			if _, err := fmt.Fprintf(w, "%s %s: %s -> %s\n", req.Path, version, mount.Source, mount.Target); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
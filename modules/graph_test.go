@@ -0,0 +1,44 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestPrintGraph(t *testing.T) {
+	manifest := &Manifest{
+		Requires: []Requirement{
+			{
+				Path:    "github.com/brandur/singularity-theme-paper",
+				Version: "v1.2.0",
+				Mounts: []Mount{
+					{Source: "layouts", Target: "layouts"},
+				},
+			},
+			{
+				// A remote module with no pinned version still fetches at
+				// HEAD (see Fetch); it must not be mislabeled "(local)" just
+				// because Version is empty.
+				Path: "github.com/brandur/some-theme",
+				Mounts: []Mount{
+					{Source: "layouts", Target: "layouts"},
+				},
+			},
+			{
+				Path:   "themes/local-experiment",
+				Mounts: []Mount{{Source: ".", Target: "layouts"}},
+			},
+		},
+	}
+
+	var buf strings.Builder
+	assert.NoError(t, PrintGraph(&buf, manifest))
+
+	assert.Equal(t,
+		"github.com/brandur/singularity-theme-paper v1.2.0: layouts -> layouts\n"+
+			"github.com/brandur/some-theme : layouts -> layouts\n"+
+			"themes/local-experiment (local): . -> layouts\n",
+		buf.String())
+}
@@ -0,0 +1,235 @@
+// Package modules implements a small theme/module system for Singularity
+// sites: a site's singularity.mod manifest can import one or more external
+// "modules" (either a local directory under themes/, or a Git-fetched
+// module-style path) that each contribute files under the project's seven
+// standard component roots (articles, pages, layouts, content/images,
+// content/stylesheets, content/javascripts, content/fonts).
+//
+// A project's own files always take precedence over anything contributed by
+// an imported module, and modules override each other in import order (a
+// later import wins over an earlier one for the same target path).
+package modules
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ManifestFile is the name of the manifest file a project keeps at its root.
+const ManifestFile = "singularity.mod"
+
+// StandardRoots are the seven component roots that the project itself, or
+// any module it imports, can contribute files under.
+var StandardRoots = []string{
+	"articles",
+	"pages",
+	"layouts",
+	"content/images",
+	"content/stylesheets",
+	"content/javascripts",
+	"content/fonts",
+}
+
+// Manifest is the parsed form of a singularity.mod file.
+type Manifest struct {
+	// Module is this project's own module path, set by `mod init`. It's only
+	// meaningful if the project is itself going to be imported as a module
+	// by some other site.
+	Module string
+
+	// Requires are the modules this project imports, in the order they were
+	// declared (later entries take precedence over earlier ones).
+	Requires []Requirement
+}
+
+// Requirement is a single imported module and the mounts it contributes.
+type Requirement struct {
+	// Path identifies the module. A path beginning with "themes/" refers to
+	// a local directory relative to the project root; anything else is
+	// treated as a Git-fetchable, Go-module-style import path.
+	Path string
+
+	// Version is the Git tag or branch to fetch for a remote module. It's
+	// passed to `git clone --branch`, which doesn't accept a bare commit
+	// hash. It's ignored for local "themes/" modules.
+	Version string
+
+	// Mounts declare which of the module's subdirectories populate which of
+	// the project's standard component roots.
+	Mounts []Mount
+}
+
+// IsLocal reports whether the requirement refers to a local theme directory
+// rather than a remote, Git-fetched module.
+func (r Requirement) IsLocal() bool {
+	return strings.HasPrefix(r.Path, "themes/")
+}
+
+// Mount maps a subdirectory of a module (Source) onto one of the project's
+// standard component roots (Target).
+type Mount struct {
+	Source string
+	Target string
+}
+
+// ParseManifest reads a singularity.mod file. The format intentionally
+// mirrors go.mod: one `require` per imported module, followed by indented
+// `mount` lines declaring what it contributes.
+//
+//	module example.com/myblog
+//
+//	require github.com/brandur/singularity-theme-paper v1.2.0
+//		mount layouts -> layouts
+//		mount content/images -> content/images
+//
+//	require themes/local-experiment
+//		mount . -> layouts
+func ParseManifest(r io.Reader) (*Manifest, error) {
+	manifest := &Manifest{}
+
+	var current *Requirement
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		rawLine := scanner.Text()
+		line := strings.TrimSpace(rawLine)
+
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		indented := rawLine != line
+
+		if indented {
+			if current == nil {
+				return nil, fmt.Errorf("%s:%d: mount line with no preceding require", ManifestFile, lineNum)
+			}
+
+			mount, err := parseMountLine(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", ManifestFile, lineNum, err)
+			}
+			current.Mounts = append(current.Mounts, mount)
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		switch fields[0] {
+		case "module":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("%s:%d: expected `module <path>`", ManifestFile, lineNum)
+			}
+			manifest.Module = fields[1]
+
+		case "require":
+			if len(fields) < 2 || len(fields) > 3 {
+				return nil, fmt.Errorf("%s:%d: expected `require <path> [version]`", ManifestFile, lineNum)
+			}
+			req := Requirement{Path: fields[1]}
+			if len(fields) == 3 {
+				req.Version = fields[2]
+			}
+			manifest.Requires = append(manifest.Requires, req)
+			current = &manifest.Requires[len(manifest.Requires)-1]
+
+		default:
+			return nil, fmt.Errorf("%s:%d: unrecognized directive %q", ManifestFile, lineNum, fields[0])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func parseMountLine(line string) (Mount, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[0] != "mount" || fields[2] != "->" {
+		return Mount{}, fmt.Errorf("expected `mount <source> -> <target>`, got %q", line)
+	}
+
+	target := fields[3]
+	if !isStandardRoot(target) {
+		return Mount{}, fmt.Errorf("mount target %q is not one of the standard component roots %v", target, StandardRoots)
+	}
+
+	return Mount{Source: fields[1], Target: target}, nil
+}
+
+func isStandardRoot(root string) bool {
+	for _, r := range StandardRoots {
+		if r == root {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteManifest serializes manifest back out in the same format ParseManifest
+// reads.
+func WriteManifest(w io.Writer, manifest *Manifest) error {
+	if manifest.Module != "" {
+		if _, err := fmt.Fprintf(w, "module %s\n\n", manifest.Module); err != nil {
+			return err
+		}
+	}
+
+	for i, req := range manifest.Requires {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+
+		if req.Version != "" {
+			if _, err := fmt.Fprintf(w, "require %s %s\n", req.Path, req.Version); err != nil {
+				return err
+			}
+		} else {
+			if _, err := fmt.Fprintf(w, "require %s\n", req.Path); err != nil {
+				return err
+			}
+		}
+
+		for _, mount := range req.Mounts {
+			if _, err := fmt.Fprintf(w, "\tmount %s -> %s\n", mount.Source, mount.Target); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadManifest reads and parses the manifest at path. It returns (nil, nil)
+// if no manifest file exists, which callers should treat as "this project
+// doesn't use modules."
+func LoadManifest(path string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseManifest(f)
+}
+
+// SaveManifest writes manifest to path, creating or truncating it.
+func SaveManifest(path string, manifest *Manifest) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return WriteManifest(f, manifest)
+}
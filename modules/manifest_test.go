@@ -0,0 +1,62 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+const testManifest = `module example.com/myblog
+
+require github.com/brandur/singularity-theme-paper v1.2.0
+	mount layouts -> layouts
+	mount content/images -> content/images
+
+require themes/local-experiment
+	mount . -> layouts
+`
+
+func TestParseManifest(t *testing.T) {
+	manifest, err := ParseManifest(strings.NewReader(testManifest))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "example.com/myblog", manifest.Module)
+	assert.Equal(t, 2, len(manifest.Requires))
+
+	first := manifest.Requires[0]
+	assert.Equal(t, "github.com/brandur/singularity-theme-paper", first.Path)
+	assert.Equal(t, "v1.2.0", first.Version)
+	assert.False(t, first.IsLocal())
+	assert.Equal(t, []Mount{
+		{Source: "layouts", Target: "layouts"},
+		{Source: "content/images", Target: "content/images"},
+	}, first.Mounts)
+
+	second := manifest.Requires[1]
+	assert.Equal(t, "themes/local-experiment", second.Path)
+	assert.True(t, second.IsLocal())
+}
+
+func TestParseManifestErrors(t *testing.T) {
+	_, err := ParseManifest(strings.NewReader("\tmount a -> layouts\n"))
+	assert.Error(t, err)
+
+	_, err = ParseManifest(strings.NewReader("require foo\n\tmount a -> nonsense\n"))
+	assert.Error(t, err)
+
+	_, err = ParseManifest(strings.NewReader("bogus directive\n"))
+	assert.Error(t, err)
+}
+
+func TestWriteManifestRoundTrip(t *testing.T) {
+	manifest, err := ParseManifest(strings.NewReader(testManifest))
+	assert.NoError(t, err)
+
+	var buf strings.Builder
+	assert.NoError(t, WriteManifest(&buf, manifest))
+
+	reparsed, err := ParseManifest(strings.NewReader(buf.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, manifest, reparsed)
+}